@@ -0,0 +1,208 @@
+// Package circom writes constraint systems and witnesses in the binary formats of the
+// circom ecosystem (iden3 binfileutils), to cross-check circuits with tools such as snarkjs.
+//
+// Both formats are made of sections
+//
+//	file     ->  [magic | uint32(version) | uint32(nbSections) | sections]
+//	section  ->  [uint32(type) | uint64(size) | content]
+//
+// with little-endian integers and field elements in canonical (non-Montgomery) form.
+//
+// # Wire ordering
+//
+// circom orders the wires as [one | public outputs | public inputs | private inputs | internal],
+// which matches the ordering of cs.R1CS ([one | public | secret | internal]) with no
+// public output; wire ids are thus kept as is, and labels are the wire ids.
+package circom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+const (
+	r1csMagic   = "r1cs"
+	r1csVersion = 1
+
+	r1csSectionHeader      = 1
+	r1csSectionConstraints = 2
+	r1csSectionWire2Label  = 3
+
+	wtnsMagic   = "wtns"
+	wtnsVersion = 2
+
+	wtnsSectionHeader = 1
+	wtnsSectionData   = 2
+)
+
+// WriteR1CS writes the constraint system in the circom .r1cs format.
+// Terms with a zero coefficient are omitted.
+func WriteR1CS(w io.Writer, r1cs *cs.R1CS) error {
+	nbPublic, nbSecret := r1cs.GetNbPublicVariables(), r1cs.GetNbSecretVariables()
+	if nbPublic == 0 {
+		return fmt.Errorf("constraint system has no constant one wire")
+	}
+	nbWires := nbPublic + nbSecret + r1cs.GetNbInternalVariables()
+
+	// first pass to compute the size of the constraints section; circom constraints are
+	// written in order, all constraints must be R1C.
+	var constraintsSize uint64
+	nbConstraints := 0
+	err := r1cs.ForEachR1C(func(_, cID int, r1c *cs.R1C) error {
+		if cID != nbConstraints {
+			return fmt.Errorf("constraint #%d is not a R1C", nbConstraints)
+		}
+		nbConstraints++
+		for _, l := range []cs.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			constraintsSize += 4 + uint64(nbNonZero(l))*(4+fr.Bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if nbConstraints != r1cs.GetNbConstraints() {
+		return fmt.Errorf("constraint #%d is not a R1C", nbConstraints)
+	}
+
+	bw := &writer{w: bufio.NewWriter(w)}
+	bw.write([]byte(r1csMagic))
+	bw.writeUint32(r1csVersion)
+	bw.writeUint32(3)
+
+	bw.writeUint32(r1csSectionHeader)
+	bw.writeUint64(4 + fr.Bytes + 4*4 + 8 + 4)
+	bw.writeUint32(fr.Bytes)
+	bw.writeModulus()
+	bw.writeUint32(uint32(nbWires))
+	bw.writeUint32(0) // public outputs
+	bw.writeUint32(uint32(nbPublic - 1))
+	bw.writeUint32(uint32(nbSecret))
+	bw.writeUint64(uint64(nbWires)) // labels
+	bw.writeUint32(uint32(r1cs.GetNbConstraints()))
+
+	bw.writeUint32(r1csSectionConstraints)
+	bw.writeUint64(constraintsSize)
+	_ = r1cs.ForEachR1C(func(_, _ int, r1c *cs.R1C) error {
+		for _, l := range []cs.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			bw.writeUint32(uint32(nbNonZero(l)))
+			for _, t := range l {
+				if t.CoeffID() == cs.CoeffIdZero {
+					continue
+				}
+				bw.writeUint32(uint32(t.WireID()))
+				bw.writeElement(&r1cs.Coefficients[t.CoeffID()])
+			}
+		}
+		return nil
+	})
+
+	bw.writeUint32(r1csSectionWire2Label)
+	bw.writeUint64(8 * uint64(nbWires))
+	for i := 0; i < nbWires; i++ {
+		bw.writeUint64(uint64(i))
+	}
+
+	return bw.flush()
+}
+
+// WriteWtns writes a full witness (the solution vector of a R1CS, cs.R1CSSolution.W,
+// starting with the one wire) in the circom .wtns format.
+func WriteWtns(w io.Writer, values fr.Vector) error {
+	bw := &writer{w: bufio.NewWriter(w)}
+	bw.write([]byte(wtnsMagic))
+	bw.writeUint32(wtnsVersion)
+	bw.writeUint32(2)
+
+	bw.writeUint32(wtnsSectionHeader)
+	bw.writeUint64(4 + fr.Bytes + 4)
+	bw.writeUint32(fr.Bytes)
+	bw.writeModulus()
+	bw.writeUint32(uint32(len(values)))
+
+	bw.writeUint32(wtnsSectionData)
+	bw.writeUint64(uint64(len(values)) * fr.Bytes)
+	for i := range values {
+		bw.writeElement(&values[i])
+	}
+
+	return bw.flush()
+}
+
+// WriteInputWtns writes the inputs of a witness in the circom .wtns format, prefixed with
+// the one wire: [1 | public | secret]. The internal wires are not included; see WriteWtns
+// to write a solved witness.
+func WriteInputWtns(w io.Writer, wit witness.Witness) error {
+	v, ok := wit.Vector().(fr.Vector)
+	if !ok {
+		return fmt.Errorf("unexpected witness vector type %T", wit.Vector())
+	}
+	values := make(fr.Vector, len(v)+1)
+	values[0].SetOne()
+	copy(values[1:], v)
+	return WriteWtns(w, values)
+}
+
+func nbNonZero(l cs.LinearExpression) int {
+	n := 0
+	for _, t := range l {
+		if t.CoeffID() != cs.CoeffIdZero {
+			n++
+		}
+	}
+	return n
+}
+
+// writer wraps a buffered writer and keeps the first error.
+type writer struct {
+	w   *bufio.Writer
+	buf [fr.Bytes]byte
+	err error
+}
+
+func (w *writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.Write(b)
+}
+
+func (w *writer) writeUint32(v uint32) {
+	binary.LittleEndian.PutUint32(w.buf[:4], v)
+	w.write(w.buf[:4])
+}
+
+func (w *writer) writeUint64(v uint64) {
+	binary.LittleEndian.PutUint64(w.buf[:8], v)
+	w.write(w.buf[:8])
+}
+
+// writeElement writes e in canonical little-endian form.
+func (w *writer) writeElement(e *fr.Element) {
+	b := e.Bytes()
+	for i := range b {
+		w.buf[i] = b[len(b)-1-i]
+	}
+	w.write(w.buf[:])
+}
+
+func (w *writer) writeModulus() {
+	q := fr.Modulus().FillBytes(make([]byte, fr.Bytes))
+	for i := range q {
+		w.buf[i] = q[len(q)-1-i]
+	}
+	w.write(w.buf[:])
+}
+
+func (w *writer) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
@@ -0,0 +1,102 @@
+// Command hintplugin is a stand-in hint plugin, to test the external hints of the solver
+// (package hintsolver/external) without a real plugin.
+//
+// Usage:
+//
+//	hintplugin [-socket path]
+//
+// It serves the requests on its standard input and output, or on the Unix socket at path.
+// The hints are:
+//
+//	square   outputs[i] = inputs[i]² mod q
+//	sum      outputs[0] = Σ inputs mod q
+//	inv_zero outputs[0] = 1/inputs[0] mod q, or 0 if inputs[0] == 0
+//	sleep    sleeps inputs[0] milliseconds, then outputs[i] = inputs[i]
+//	fail     returns an error
+//	exit     exits with status inputs[0], to test the restarts
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver/external"
+)
+
+var hints = map[string]hintsolver.HintFn{
+	"square": func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		if len(inputs) != len(outputs) {
+			return errors.New("square: expected as many outputs as inputs")
+		}
+		for i := range inputs {
+			outputs[i].Mul(inputs[i], inputs[i]).Mod(outputs[i], q)
+		}
+		return nil
+	},
+	"sum": func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		if len(outputs) != 1 {
+			return errors.New("sum: expected 1 output")
+		}
+		for _, in := range inputs {
+			outputs[0].Add(outputs[0], in)
+		}
+		outputs[0].Mod(outputs[0], q)
+		return nil
+	},
+	"inv_zero": hintsolver.InvZeroHint,
+	"sleep": func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		if len(inputs) == 0 || len(inputs) != len(outputs) {
+			return errors.New("sleep: expected as many outputs as inputs, at least 1")
+		}
+		time.Sleep(time.Duration(inputs[0].Uint64()) * time.Millisecond)
+		for i := range inputs {
+			outputs[i].Set(inputs[i])
+		}
+		return nil
+	},
+	"fail": func(*big.Int, []*big.Int, []*big.Int) error {
+		return errors.New("fail: failing as requested")
+	},
+	"exit": func(_ *big.Int, inputs []*big.Int, _ []*big.Int) error {
+		code := 1
+		if len(inputs) > 0 {
+			code = int(inputs[0].Int64())
+		}
+		os.Exit(code)
+		return nil
+	},
+}
+
+func main() {
+	socket := flag.String("socket", "", "serve on the Unix socket at `path` instead of stdin and stdout")
+	flag.Parse()
+	if err := run(*socket); err != nil {
+		fmt.Fprintf(os.Stderr, "hintplugin: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(socket string) error {
+	if socket == "" {
+		return external.Serve(os.Stdin, os.Stdout, hints)
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	// close the listener on interrupt, which removes the socket file
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		l.Close()
+	}()
+	return external.ServeListener(l, hints)
+}
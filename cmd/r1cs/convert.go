@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"os"
+
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+)
+
+func convert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	in := fs.String("in", "", "gob or upstream gnark encoded constraint system")
+	out := fs.String("out", "", "output file for the binary encoded constraint system")
+	symbols := fs.String("symbols", "", "output file for the symbol table, if the constraint system has debug info")
+	fs.Parse(args)
+	if *in == "" || *out == "" {
+		fs.Usage()
+		return errors.New("-in and -out are required")
+	}
+
+	ccs, err := readR1CS(*in)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*out, func(w *bufio.Writer) error {
+		_, err := ccs.WriteTo(w)
+		return err
+	}); err != nil {
+		return err
+	}
+	if *symbols == "" {
+		return nil
+	}
+	if ccs.SymbolTable == nil {
+		return errors.New("constraint system has no debug info")
+	}
+	return writeFile(*symbols, func(w *bufio.Writer) error {
+		_, err := ccs.WriteSymbolTableTo(w)
+		return err
+	})
+}
+
+// writeFile creates path and writes it with f through a buffered writer.
+func writeFile(path string, f func(w *bufio.Writer) error) error {
+	fout, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(fout)
+	if err := f(w); err != nil {
+		fout.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		fout.Close()
+		return err
+	}
+	return fout.Close()
+}
+
+// readSymbolTable loads the symbol table at path into ccs.
+func readSymbolTable(ccs *cs.R1CS, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = ccs.ReadSymbolTableFrom(bufio.NewReader(f))
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+)
+
+func diff(args []string) error {
+	// exits with status 1 if the systems differ, like diff(1).
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	maxConstraints := fs.Int("n", 10, "maximum number of differing constraints to report")
+	asJSON := fs.Bool("json", false, "print the differences as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected two constraint system files")
+	}
+
+	a, err := readR1CS(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := readR1CS(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	d := cs.DiffR1CS(a, b, *maxConstraints)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	} else if d.Equal() {
+		fmt.Println("constraint systems are identical")
+	} else {
+		fmt.Print(d)
+	}
+	if !d.Equal() {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/circom"
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+)
+
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text, json, coo, csr or circom")
+	coeffs := fs.String("coeffs", "dec", "coefficient format for json, coo and csr: dec or hex")
+	out := fs.String("out", "", "output file (default stdout)")
+	symbols := fs.String("symbols", "", "symbol table naming wires and source locations in the text format")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected a constraint system file")
+	}
+
+	var coeffFormat cs.CoeffFormat
+	switch *coeffs {
+	case "dec":
+		coeffFormat = cs.CoeffDecimal
+	case "hex":
+		coeffFormat = cs.CoeffHex
+	default:
+		return fmt.Errorf("unknown coefficient format %q", *coeffs)
+	}
+
+	ccs, err := readR1CS(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *symbols != "" {
+		if err := readSymbolTable(ccs, *symbols); err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	switch *format {
+	case "text":
+		err = ccs.ExportText(bw)
+	case "json":
+		err = ccs.ExportJSON(bw, coeffFormat)
+	case "circom":
+		err = circom.WriteR1CS(bw, ccs)
+	case "coo", "csr":
+		a, b, c := ccs.Matrices()
+		for _, m := range []struct {
+			name string
+			m    *cs.SparseMatrix
+		}{{"A", &a}, {"B", &b}, {"C", &c}} {
+			fmt.Fprintf(bw, "# %s\n", m.name)
+			if *format == "coo" {
+				err = m.m.WriteCOO(bw, coeffFormat)
+			} else {
+				csr := m.m.CSR()
+				err = csr.WriteCSR(bw, coeffFormat)
+			}
+			if err != nil {
+				break
+			}
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
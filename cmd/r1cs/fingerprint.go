@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+)
+
+func fingerprint(args []string) error {
+	fs := flag.NewFlagSet("fingerprint", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected constraint system files")
+	}
+	for _, path := range fs.Args() {
+		ccs, err := readR1CS(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  %s\n", ccs.Fingerprint(), path)
+	}
+	return nil
+}
+
+// readR1CS decodes a constraint system file in any encoding accepted by cs.R1CS.ReadFrom.
+func readR1CS(path string) (*cs.R1CS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var ccs cs.R1CS
+	if _, err := ccs.ReadFrom(bufio.NewReader(f)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &ccs, nil
+}
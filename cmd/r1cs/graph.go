@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func graph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "output format: dot or json")
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected a constraint system file")
+	}
+
+	ccs, err := readR1CS(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	g := ccs.DependencyGraph()
+	switch *format {
+	case "dot":
+		err = g.WriteDOT(bw)
+	case "json":
+		err = json.NewEncoder(bw).Encode(g)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
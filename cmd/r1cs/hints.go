@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints"
+)
+
+func hintsCmd(args []string) error {
+	fs := flag.NewFlagSet("hints", flag.ExitOnError)
+	version := fs.String("gnark", "", "gnark version the constraint system was compiled with (the version stored in the system if empty, or any known version)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected a constraint system file")
+	}
+	if *version != "" {
+		if _, ok := hints.UpstreamAliases(*version); !ok {
+			return fmt.Errorf("unknown gnark version %s, known versions: %v", *version, hints.UpstreamVersions())
+		}
+	}
+
+	ccs, err := readR1CS(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *version == "" {
+		*version = ccs.GnarkVersion
+	}
+	_, report := hints.ResolveHintDependencies(ccs.MHintsDependencies, *version)
+	fmt.Print(report)
+	return nil
+}
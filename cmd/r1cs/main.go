@@ -0,0 +1,59 @@
+// Command r1cs provides tools to manipulate serialized constraint systems.
+//
+// Usage:
+//
+//	r1cs <command> [flags]
+//
+// Run r1cs <command> -h for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"convert":     {"convert a gob or gnark encoded constraint system to the binary format", convert},
+	"diff":        {"compare two constraint systems", diff},
+	"export":      {"export a constraint system as text, JSON, sparse matrices or circom .r1cs", export},
+	"fingerprint": {"print the fingerprint of constraint systems", fingerprint},
+	"hints":       {"resolve the hints of a constraint system, including upstream gnark names", hintsCmd},
+	"graph":       {"export the instruction dependency graph as DOT or JSON", graph},
+	"inspect":     {"print statistics about a constraint system", inspect},
+	"optimize":    {"optimize a constraint system", optimize},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "r1cs %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: r1cs <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
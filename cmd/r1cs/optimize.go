@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
+)
+
+func optimize(args []string) error {
+	config := cs.DefaultOptimizeConfig()
+	fs := flag.NewFlagSet("optimize", flag.ExitOnError)
+	out := fs.String("out", "", "output file for the optimized constraint system")
+	reportPath := fs.String("report", "", "output file for the JSON report, including the wire map")
+	fs.BoolVar(&config.RemoveDuplicates, "dedup", config.RemoveDuplicates, "remove duplicate constraints")
+	fs.BoolVar(&config.DropUnusedCoefficients, "coeffs", config.DropUnusedCoefficients, "drop unused coefficients")
+	fs.BoolVar(&config.CompactBlueprints, "compact", config.CompactBlueprints, "use fixed-shape blueprints")
+	fs.BoolVar(&config.MergeLevels, "levels", config.MergeLevels, "merge small adjacent levels")
+	fs.BoolVar(&config.RenumberWires, "wires", config.RenumberWires, "remove unused internal wires")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *out == "" {
+		fs.Usage()
+		return errors.New("expected a constraint system file and -out")
+	}
+
+	ccs, err := readR1CS(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	optimized, report, err := ccs.Optimize(config)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err := optimized.WriteTo(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if *reportPath != "" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*reportPath, b, 0o644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("duplicates removed: %d\n", report.NbDuplicates)
+	fmt.Printf("coefficients:       %d -> %d\n", report.NbCoefficientsBefore, report.NbCoefficientsAfter)
+	fmt.Printf("calldata bytes:     %d -> %d\n", report.CallDataBefore, report.CallDataAfter)
+	fmt.Printf("levels:             %d -> %d\n", report.NbLevelsBefore, report.NbLevelsAfter)
+	fmt.Printf("internal wires:     %d -> %d\n", report.NbInternalBefore, report.NbInternalAfter)
+	if report.MatrixChanged {
+		fmt.Println("the constraint matrix changed, a new setup is required")
+	} else {
+		fmt.Println("the constraint matrix is unchanged, existing keys still apply")
+	}
+	return nil
+}
@@ -1,6 +1,11 @@
 package cs
 
-import csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+import (
+	"errors"
+	"fmt"
+
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
 
 type BlueprintID uint32
 
@@ -29,11 +34,39 @@ type Solver interface {
 }
 
 // BlueprintSolvable represents a blueprint that knows how to solve itself.
+//
+// The solver calls Solve when processing an instruction using this blueprint, in place of
+// the generic R1C and hint logic. If the blueprint also implements BlueprintR1C, the
+// decompressed constraint is then checked and contributes its a, b, c rows to the solution;
+// in that case Solve must have instantiated all the wires of the constraint.
+//
+// Custom blueprints must be registered with RegisterBlueprint to be serialized.
 type BlueprintSolvable interface {
 	// Solve may return an error if the decoded constraint / calldata is unsolvable.
 	Solve(s Solver, calldata []uint32) error
 }
 
+// BlueprintWires exposes the wires of the instructions of a BlueprintSolvable that is
+// neither a BlueprintR1C nor a BlueprintHint. It is needed to add such instructions to a
+// system (AddInstruction) and to analyze them (AnalyzeWires, DependencyGraph, Optimize).
+//
+// The iterators follow the convention of Iterable.WireIterator.
+type BlueprintWires interface {
+	// InputWires returns an iterator over the wires the instruction reads.
+	InputWires(calldata []uint32) (next func() int)
+
+	// OutputWires returns an iterator over the wires the instruction solves.
+	OutputWires(calldata []uint32) (next func() int)
+}
+
+// BlueprintTagged is implemented by blueprints with a stable tag, identifying their
+// calldata layout and semantics independently of the Go package and type names. The
+// fingerprint of a system hashes the tags of its blueprints (see Fingerprint); a tag must
+// change, e.g. with a version suffix, when the blueprint changes.
+type BlueprintTagged interface {
+	BlueprintTag() string
+}
+
 // BlueprintR1C indicates that the blueprint and associated calldata encodes a R1C
 type BlueprintR1C interface {
 	CompressR1C(c *R1C) []uint32
@@ -107,12 +140,32 @@ func (b *BlueprintGenericHint) CompressHint(h HintMapping) []uint32 {
 	return r
 }
 
+func (b *BlueprintGenericHint) checkCallData(calldata []uint32) error {
+	if len(calldata) < 5 {
+		return errors.New("truncated hint")
+	}
+	j := uint64(3)
+	for i := uint32(0); i < calldata[2]; i++ {
+		if j >= uint64(len(calldata)) {
+			return errors.New("truncated hint inputs")
+		}
+		j += 1 + 2*uint64(calldata[j])
+	}
+	if j+2 != uint64(len(calldata)) {
+		return fmt.Errorf("hint inputs of %d calldata, expected %d", j-3, len(calldata)-5)
+	}
+	return nil
+}
+
 func (b *BlueprintGenericHint) NbInputs() int {
 	return -1
 }
 func (b *BlueprintGenericHint) NbConstraints() int {
 	return 0
 }
+func (b *BlueprintGenericHint) BlueprintTag() string {
+	return "generic_hint/v1"
+}
 
 // BlueprintGenericR1C implements Blueprint and BlueprintR1C.
 // Encodes
@@ -127,6 +180,9 @@ func (b *BlueprintGenericR1C) NbInputs() int {
 func (b *BlueprintGenericR1C) NbConstraints() int {
 	return 1
 }
+func (b *BlueprintGenericR1C) BlueprintTag() string {
+	return "generic_r1c/v1"
+}
 
 func (b *BlueprintGenericR1C) CompressR1C(c *R1C) []uint32 {
 	// we store total nb inputs, len L, len R, len O, and then the "flatten" linear expressions
@@ -146,6 +202,17 @@ func (b *BlueprintGenericR1C) CompressR1C(c *R1C) []uint32 {
 	return r
 }
 
+func (b *BlueprintGenericR1C) checkCallData(calldata []uint32) error {
+	if len(calldata) < 4 {
+		return errors.New("truncated constraint")
+	}
+	n := 4 + 2*(uint64(calldata[1])+uint64(calldata[2])+uint64(calldata[3]))
+	if n != uint64(len(calldata)) {
+		return fmt.Errorf("constraint of %d calldata, expected %d", n, len(calldata))
+	}
+	return nil
+}
+
 func (b *BlueprintGenericR1C) DecompressR1C(c *R1C, calldata []uint32) {
 	copySlice := func(slice *LinearExpression, expectedLen, idx int) {
 		if cap(*slice) >= expectedLen {
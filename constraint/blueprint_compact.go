@@ -0,0 +1,235 @@
+package cs
+
+import "fmt"
+
+// Fixed-shape blueprints encode the most common constraint shapes emitted by the gnark
+// frontend with a static (or much smaller) calldata than BlueprintGenericR1C, which stores
+// 4 + 2*(len(L)+len(R)+len(O)) uint32 per constraint.
+//
+// They decompress to the exact same R1C than the generic blueprint, such that rewriting a
+// system with CompactBlueprints doesn't change the constraint matrix (and the proving key).
+
+// BlueprintBooleanR1C implements Blueprint and BlueprintR1C.
+// Encodes
+//
+//	cL⋅x ⋅ (1 + cR⋅x) == 0
+//
+// as emitted by AssertIsBoolean; calldata is [cL, x, cR].
+type BlueprintBooleanR1C struct{}
+
+func (b *BlueprintBooleanR1C) NbInputs() int {
+	return 3
+}
+func (b *BlueprintBooleanR1C) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintBooleanR1C) BlueprintTag() string {
+	return "boolean_r1c/v1"
+}
+
+func (b *BlueprintBooleanR1C) accepts(c *R1C) bool {
+	return len(c.L) == 1 && len(c.R) == 2 && len(c.O) == 1 &&
+		c.R[0].CID == CoeffIdOne && c.R[0].VID == 0 &&
+		c.R[1].VID == c.L[0].VID &&
+		c.O[0].CID == CoeffIdZero && c.O[0].VID == 0
+}
+
+func (b *BlueprintBooleanR1C) CompressR1C(c *R1C) []uint32 {
+	r := getBuffer(3)
+	r = append(r, c.L[0].CID, c.L[0].VID, c.R[1].CID)
+	return r
+}
+
+func (b *BlueprintBooleanR1C) DecompressR1C(c *R1C, calldata []uint32) {
+	c.L = resizeLinearExpression(c.L, 1)
+	c.R = resizeLinearExpression(c.R, 2)
+	c.O = resizeLinearExpression(c.O, 1)
+	c.L[0] = Term{CID: calldata[0], VID: calldata[1]}
+	c.R[0] = Term{CID: CoeffIdOne, VID: 0}
+	c.R[1] = Term{CID: calldata[2], VID: calldata[1]}
+	c.O[0] = Term{CID: CoeffIdZero, VID: 0}
+}
+
+// BlueprintMulR1C implements Blueprint and BlueprintR1C.
+// Encodes
+//
+//	cL⋅l ⋅ cR⋅r == cO⋅o
+//
+// calldata is [cL, l, cR, r, cO, o].
+type BlueprintMulR1C struct{}
+
+func (b *BlueprintMulR1C) NbInputs() int {
+	return 6
+}
+func (b *BlueprintMulR1C) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintMulR1C) BlueprintTag() string {
+	return "mul_r1c/v1"
+}
+
+func (b *BlueprintMulR1C) accepts(c *R1C) bool {
+	return len(c.L) == 1 && len(c.R) == 1 && len(c.O) == 1
+}
+
+func (b *BlueprintMulR1C) CompressR1C(c *R1C) []uint32 {
+	r := getBuffer(6)
+	r = append(r, c.L[0].CID, c.L[0].VID, c.R[0].CID, c.R[0].VID, c.O[0].CID, c.O[0].VID)
+	return r
+}
+
+func (b *BlueprintMulR1C) DecompressR1C(c *R1C, calldata []uint32) {
+	c.L = resizeLinearExpression(c.L, 1)
+	c.R = resizeLinearExpression(c.R, 1)
+	c.O = resizeLinearExpression(c.O, 1)
+	c.L[0] = Term{CID: calldata[0], VID: calldata[1]}
+	c.R[0] = Term{CID: calldata[2], VID: calldata[3]}
+	c.O[0] = Term{CID: calldata[4], VID: calldata[5]}
+}
+
+// BlueprintLinearR1C implements Blueprint and BlueprintR1C.
+// Encodes
+//
+//	1 ⋅ (Σ ci⋅ri) == cO⋅o
+//
+// as emitted by AssertIsEqual, typically for bit decompositions.
+// calldata is [nbInputs, cO, o, c0, r0, c1, r1, ...].
+type BlueprintLinearR1C struct{}
+
+func (b *BlueprintLinearR1C) NbInputs() int {
+	// size of the linear expression is unknown.
+	return -1
+}
+func (b *BlueprintLinearR1C) NbConstraints() int {
+	return 1
+}
+func (b *BlueprintLinearR1C) BlueprintTag() string {
+	return "linear_r1c/v1"
+}
+
+func (b *BlueprintLinearR1C) accepts(c *R1C) bool {
+	return len(c.L) == 1 && len(c.O) == 1 &&
+		c.L[0].CID == CoeffIdOne && c.L[0].VID == 0
+}
+
+func (b *BlueprintLinearR1C) CompressR1C(c *R1C) []uint32 {
+	nbInputs := 3 + 2*len(c.R)
+	r := getBuffer(nbInputs)
+	r = append(r, uint32(nbInputs), c.O[0].CID, c.O[0].VID)
+	for _, t := range c.R {
+		r = append(r, t.CID, t.VID)
+	}
+	return r
+}
+
+func (b *BlueprintLinearR1C) checkCallData(calldata []uint32) error {
+	if len(calldata) < 3 || (len(calldata)-3)%2 != 0 {
+		return fmt.Errorf("linear constraint of %d calldata", len(calldata))
+	}
+	return nil
+}
+
+func (b *BlueprintLinearR1C) DecompressR1C(c *R1C, calldata []uint32) {
+	lenR := (int(calldata[0]) - 3) / 2
+	c.L = resizeLinearExpression(c.L, 1)
+	c.R = resizeLinearExpression(c.R, lenR)
+	c.O = resizeLinearExpression(c.O, 1)
+	c.L[0] = Term{CID: CoeffIdOne, VID: 0}
+	c.O[0] = Term{CID: calldata[1], VID: calldata[2]}
+	j := 3
+	for k := 0; k < lenR; k++ {
+		c.R[k] = Term{CID: calldata[j], VID: calldata[j+1]}
+		j += 2
+	}
+}
+
+// resizeLinearExpression returns a linear expression of length n, re-using l memory if possible.
+func resizeLinearExpression(l LinearExpression, n int) LinearExpression {
+	if cap(l) >= n {
+		return l[:n]
+	}
+	return make(LinearExpression, n, n*2)
+}
+
+// CompactionReport summarizes the effect of CompactBlueprints on a constraint system.
+type CompactionReport struct {
+	// NbRewritten maps the name of the fixed-shape blueprints to the number of
+	// instructions rewritten to use them.
+	NbRewritten map[string]int `json:"nbRewritten"`
+
+	// CallDataBefore and CallDataAfter are the calldata sizes, in bytes.
+	CallDataBefore int `json:"callDataBefore"`
+	CallDataAfter  int `json:"callDataAfter"`
+}
+
+// Saved returns the number of calldata bytes saved by the compaction.
+func (r *CompactionReport) Saved() int {
+	return r.CallDataBefore - r.CallDataAfter
+}
+
+// CompactBlueprints rewrites the instructions encoded with BlueprintGenericR1C to use
+// fixed-shape blueprints (BlueprintBooleanR1C, BlueprintMulR1C, BlueprintLinearR1C)
+// when the constraint matches their shape exactly.
+//
+// Constraints, wires and levels are unchanged; only Instructions, CallData and Blueprints
+// are updated.
+func (cs *System) CompactBlueprints() CompactionReport {
+	type compactBlueprint interface {
+		Blueprint
+		BlueprintR1C
+		accepts(c *R1C) bool
+	}
+	candidates := []compactBlueprint{&BlueprintBooleanR1C{}, &BlueprintMulR1C{}, &BlueprintLinearR1C{}}
+
+	report := CompactionReport{
+		NbRewritten:    make(map[string]int, len(candidates)),
+		CallDataBefore: 4 * len(cs.CallData),
+	}
+
+	// blueprint ids of the candidates, added lazily to the system.
+	ids := make([]int, len(candidates))
+	for i := range ids {
+		ids[i] = -1
+		for j, b := range cs.Blueprints {
+			if BlueprintName(b) == BlueprintName(candidates[i]) {
+				ids[i] = j
+				break
+			}
+		}
+	}
+
+	callData := make([]uint32, 0, len(cs.CallData))
+	var r1c R1C
+	for i, inst := range cs.Instructions {
+		calldata := cs.GetCallData(inst)
+		cs.Instructions[i].StartCallData = uint64(len(callData))
+
+		if _, ok := cs.Blueprints[inst.BlueprintID].(*BlueprintGenericR1C); !ok {
+			callData = append(callData, calldata...)
+			continue
+		}
+
+		rewritten := false
+		(&BlueprintGenericR1C{}).DecompressR1C(&r1c, calldata)
+		for j, b := range candidates {
+			if !b.accepts(&r1c) {
+				continue
+			}
+			if ids[j] == -1 {
+				ids[j] = int(cs.AddBlueprint(b))
+			}
+			cs.Instructions[i].BlueprintID = BlueprintID(ids[j])
+			callData = append(callData, b.CompressR1C(&r1c)...)
+			report.NbRewritten[BlueprintName(b)]++
+			rewritten = true
+			break
+		}
+		if !rewritten {
+			callData = append(callData, calldata...)
+		}
+	}
+
+	cs.CallData = callData
+	report.CallDataAfter = 4 * len(cs.CallData)
+	return report
+}
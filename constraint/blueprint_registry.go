@@ -0,0 +1,85 @@
+package cs
+
+import (
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/consensys/gnark/logger"
+)
+
+func init() {
+	RegisterBlueprint(&BlueprintGenericHint{}, &BlueprintGenericR1C{})
+	RegisterBlueprint(&BlueprintBooleanR1C{}, &BlueprintMulR1C{}, &BlueprintLinearR1C{})
+}
+
+var (
+	blueprintRegistry  = make(map[string]reflect.Type)
+	blueprintRegistryM sync.RWMutex
+)
+
+// RegisterBlueprint registers blueprint types in the global registry.
+//
+// The constraint system stores its blueprints as a []Blueprint; custom blueprint types must
+// be registered before a constraint system using them is serialized or deserialized.
+// Registering the same type several times is a no-op.
+func RegisterBlueprint(blueprints ...Blueprint) {
+	blueprintRegistryM.Lock()
+	defer blueprintRegistryM.Unlock()
+	for _, b := range blueprints {
+		name := BlueprintName(b)
+		t := reflect.TypeOf(b)
+		if registered, ok := blueprintRegistry[name]; ok {
+			if registered != t {
+				log := logger.Logger()
+				log.Warn().Str("name", name).Msg("blueprint name registered multiple times with different types")
+			}
+			continue
+		}
+		gob.Register(b)
+		blueprintRegistry[name] = t
+	}
+}
+
+// BlueprintName returns the name under which a blueprint type is registered.
+// It is made of the package path and the type name, prefixed with '*' for pointer types.
+func BlueprintName(b Blueprint) string {
+	t := reflect.TypeOf(b)
+	star := ""
+	if t.Kind() == reflect.Pointer {
+		star = "*"
+		t = t.Elem()
+	}
+	if t.PkgPath() == "" {
+		return star + t.String()
+	}
+	return star + t.PkgPath() + "." + t.Name()
+}
+
+// NewBlueprint instantiates a registered blueprint from its name.
+func NewBlueprint(name string) (Blueprint, error) {
+	blueprintRegistryM.RLock()
+	t, ok := blueprintRegistry[name]
+	blueprintRegistryM.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("blueprint %s is not registered", name)
+	}
+	if t.Kind() == reflect.Pointer {
+		return reflect.New(t.Elem()).Interface().(Blueprint), nil
+	}
+	return reflect.New(t).Elem().Interface().(Blueprint), nil
+}
+
+// GetRegisteredBlueprints returns the sorted names of all registered blueprints.
+func GetRegisteredBlueprints() []string {
+	blueprintRegistryM.RLock()
+	defer blueprintRegistryM.RUnlock()
+	names := make([]string, 0, len(blueprintRegistry))
+	for name := range blueprintRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
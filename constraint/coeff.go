@@ -43,6 +43,9 @@ func newCoeffTable(capacity int) CoeffTable {
 }
 
 func (ct *CoeffTable) AddCoeff(coeff Element) uint32 {
+	if ct.mCoeffs == nil {
+		ct.initIndex()
+	}
 	c := (*fr.Element)(coeff[:])
 	var cID uint32
 	if c.IsZero() {
@@ -68,6 +71,21 @@ func (ct *CoeffTable) AddCoeff(coeff Element) uint32 {
 	return cID
 }
 
+// initIndex rebuilds the coefficient index from the coefficients slice, for example
+// after the table was deserialized.
+func (ct *CoeffTable) initIndex() {
+	if len(ct.Coefficients) < CoeffIdMinusTwo+1 {
+		*ct = newCoeffTable(0)
+		return
+	}
+	ct.mCoeffs = make(map[fr.Element]uint32, len(ct.Coefficients))
+	for i := CoeffIdMinusTwo + 1; i < len(ct.Coefficients); i++ {
+		if _, ok := ct.mCoeffs[ct.Coefficients[i]]; !ok {
+			ct.mCoeffs[ct.Coefficients[i]] = uint32(i)
+		}
+	}
+}
+
 func (ct *CoeffTable) MakeTerm(coeff Element, variableID int) Term {
 	cID := ct.AddCoeff(coeff)
 	return Term{VID: uint32(variableID), CID: cID}
@@ -8,6 +8,10 @@ import (
 
 const CommitmentDst = "bsb22-commitment"
 
+// CommitmentHintName is the name of the placeholder hint computing the commitment wire.
+// The prover overrides it at solving time.
+const CommitmentHintName = "bsb22_commitment"
+
 type Commitment struct {
 	Committed              []int // sorted list of id's of committed variables in groth16. in plonk, list of indexes of constraints defining committed values
 	NbPrivateCommitted     int
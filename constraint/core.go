@@ -29,6 +29,10 @@ type System struct {
 	// serialization header
 	ScalarField string
 
+	// GnarkVersion is the version of upstream gnark which serialized the system, when it
+	// was read from a gnark encoding (see ReadGnarkFrom).
+	GnarkVersion string
+
 	Type int
 
 	Instructions []Instruction
@@ -50,9 +54,9 @@ type System struct {
 	// each level contains independent constraints and can be parallelized
 	// it is guaranteed that all dependencies for constraints in a level l are solved
 	// in previous levels
-	// TODO @gbotrel these are currently updated after we add a constraint.
-	// but in case the object is built from a serialized representation
-	// we need to init the level builder lbWireLevel from the existing constraints.
+	// Levels smaller than minWorkPerCPU are solved sequentially, in order; adjacent ones
+	// may be merged (see Optimize), in which case an instruction may depend on previous
+	// instructions of its level.
 	Levels [][]int
 
 	// scalar field
@@ -62,9 +66,13 @@ type System struct {
 	// level builder
 	lbWireLevel []int    `cbor:"-"` // at which level we solve a wire. init at -1.
 	lbOutputs   []uint32 `cbor:"-"` // wire outputs for current constraint.
+	lbMinLevel  int      `cbor:"-"` // first level instructions can be added to.
 
 	CommitmentInfo Commitment
 
+	// optional debug information, serialized separately (see WriteSymbolTableTo)
+	SymbolTable *SymbolTable `cbor:"-"`
+
 	genericHint BlueprintID
 }
 
@@ -155,11 +163,168 @@ func (system *System) AddSecretVariable(name string) (idx int) {
 	return idx
 }
 
+// AddHint adds a hint instruction to the system and returns the ids of the nbOutputs
+// internal wires it solves. The hint is registered as a dependency of the system under
+// the given name; its id is derived from the name with hintsolver.GetHintID.
+func (system *System) AddHint(name string, inputs []LinearExpression, nbOutputs int) (outputs []int, err error) {
+	if nbOutputs <= 0 {
+		return nil, fmt.Errorf("hint function must return at least one output")
+	}
+	system.initBuilder()
+
+	// register the hint as dependency
+	hintID := hintsolver.GetHintID(name)
+	if id, ok := system.MHintsDependencies[hintID]; ok {
+		// hint already registered, let's ensure string id matches
+		if id != name {
+			return nil, fmt.Errorf("hint dependency registration failed; %s previously register with same UUID as %s", name, id)
+		}
+	} else {
+		if system.MHintsDependencies == nil {
+			system.MHintsDependencies = make(map[hintsolver.HintID]string)
+		}
+		system.MHintsDependencies[hintID] = name
+	}
+
+	// prepare wires
+	outputs = make([]int, nbOutputs)
+	for i := 0; i < len(outputs); i++ {
+		outputs[i] = system.AddInternalVariable()
+	}
+
+	// associate these wires with the solver hint
+	hm := HintMapping{
+		HintID: hintID,
+		Inputs: inputs,
+	}
+	hm.OutputRange.Start = uint32(outputs[0])
+	hm.OutputRange.End = uint32(outputs[len(outputs)-1]) + 1
+
+	instruction := system.compressHint(hm, system.genericHint)
+	system.Instructions = append(system.Instructions, instruction)
+
+	system.updateLevel(len(system.Instructions)-1, &hm)
+
+	return outputs, nil
+}
+
+// AddR1C adds a constraint to the system and returns its id.
+// This does not check for validity of the constraint.
+//
+// In a R1CS, the first public variable must be the constant one wire.
+func (system *System) AddR1C(c R1C, bID BlueprintID) int {
+	system.initBuilder()
+
+	instruction := system.compressR1C(&c, bID)
+	system.Instructions = append(system.Instructions, instruction)
+
+	system.updateLevel(len(system.Instructions)-1, &c)
+
+	return system.NbConstraints - 1
+}
+
+// AddInstruction adds an instruction of the blueprint bID with the given calldata to the
+// system, and returns its id.
+//
+// Unlike AddR1C and AddHint, it accepts the custom blueprints (BlueprintSolvable), which
+// must implement BlueprintWires for the instruction to be placed in a level. The wires
+// solved by the instruction must have been created beforehand (see AddInternalVariable).
+// The hint of a BlueprintHint instruction is not registered in MHintsDependencies.
+func (system *System) AddInstruction(bID BlueprintID, calldata []uint32) (int, error) {
+	if int(bID) >= len(system.Blueprints) {
+		return -1, fmt.Errorf("unknown blueprint %d", bID)
+	}
+	system.initBuilder()
+
+	blueprint := system.Blueprints[bID]
+	nbInputs := blueprint.NbInputs()
+	if nbInputs < 0 && len(calldata) != 0 {
+		nbInputs = int(calldata[0])
+	}
+	if nbInputs != len(calldata) {
+		return -1, fmt.Errorf("blueprint %d expects %d calldata, got %d", bID, nbInputs, len(calldata))
+	}
+
+	var level int
+	switch bc := blueprint.(type) {
+	case BlueprintR1C:
+		var c R1C
+		bc.DecompressR1C(&c, calldata)
+		level = system.dependencyLevel(&c)
+	case BlueprintHint:
+		var hm HintMapping
+		bc.DecompressHint(&hm, calldata)
+		level = system.dependencyLevel(&hm)
+	case BlueprintWires:
+		var err error
+		if level, err = system.wiresDependencyLevel(bc, calldata); err != nil {
+			return -1, err
+		}
+	default:
+		return -1, fmt.Errorf("blueprint %d doesn't implement BlueprintWires", bID)
+	}
+
+	iID := len(system.Instructions)
+	system.Instructions = append(system.Instructions, Instruction{
+		BlueprintID:      bID,
+		ConstraintOffset: uint32(system.NbConstraints),
+		StartCallData:    uint64(len(system.CallData)),
+	})
+	system.CallData = append(system.CallData, calldata...)
+	system.NbConstraints += blueprint.NbConstraints()
+
+	system.markOutputs(level)
+	system.appendToLevel(iID, level)
+
+	return iID, nil
+}
+
+// AddCommitment sets the commitment of the system.
+//
+// If c.HintID and c.CommitmentIndex are not set, the commitment wire is created here: a
+// hint named CommitmentHintName taking the committed wires as inputs is added to the
+// system, and its output becomes the commitment. The prover replaces the hint at
+// solving time.
 func (system *System) AddCommitment(c Commitment) error {
 	if system.CommitmentInfo.Is() {
 		return fmt.Errorf("currently only one commitment per circuit is supported")
 	}
 
+	if !c.Is() {
+		return fmt.Errorf("must commit to at least one variable")
+	}
+	nbWires := system.GetNbPublicVariables() + system.GetNbSecretVariables() + system.GetNbInternalVariables()
+	for i, wID := range c.Committed {
+		if wID <= 0 || wID >= nbWires {
+			return fmt.Errorf("committed wire %d out of range", wID)
+		}
+		if i > 0 && c.Committed[i-1] >= wID {
+			return fmt.Errorf("committed wires must be sorted and unique")
+		}
+	}
+
+	if c.HintID == 0 && c.CommitmentIndex == 0 {
+		inputs := make([]LinearExpression, len(c.Committed))
+		for i, wID := range c.Committed {
+			inputs[i] = LinearExpression{{CID: CoeffIdOne, VID: uint32(wID)}}
+		}
+		outputs, err := system.AddHint(CommitmentHintName, inputs, 1)
+		if err != nil {
+			return err
+		}
+		c.HintID = hintsolver.GetHintID(CommitmentHintName)
+		c.CommitmentIndex = outputs[0]
+	}
+
+	if c.CommitmentIndex <= c.Committed[len(c.Committed)-1] {
+		return fmt.Errorf("commitment variable index smaller than some committed variable indices")
+	}
+	if len(c.CommittedAndCommitment) == 0 {
+		c.CommittedAndCommitment = make([]int, 0, len(c.Committed)+1)
+		c.CommittedAndCommitment = append(c.CommittedAndCommitment, c.Committed...)
+		c.CommittedAndCommitment = append(c.CommittedAndCommitment, c.CommitmentIndex)
+	}
+
 	system.CommitmentInfo = c
 
 	return nil
@@ -193,6 +358,29 @@ func (cs *System) GetCallData(instruction Instruction) []uint32 {
 	return cs.CallData[instruction.StartCallData : instruction.StartCallData+uint64(nbInputs)]
 }
 
+// initBuilder prepares the builder state (level builder, generic hint blueprint) of a
+// system that was not created through NewSystem, typically after deserialization.
+// It is a no-op if the builder state is already initialized.
+func (system *System) initBuilder() {
+	if system.lbWireLevel != nil {
+		return
+	}
+	system.lbWireLevel = make([]int, 0, system.GetNbInternalVariables())
+
+	system.genericHint = BlueprintID(len(system.Blueprints))
+	for i, b := range system.Blueprints {
+		if _, ok := b.(*BlueprintGenericHint); ok {
+			system.genericHint = BlueprintID(i)
+			break
+		}
+	}
+	if int(system.genericHint) == len(system.Blueprints) {
+		system.AddBlueprint(&BlueprintGenericHint{})
+	}
+
+	system.initLevelBuilder()
+}
+
 func (cs *System) compressR1C(c *R1C, bID BlueprintID) Instruction {
 	inst := Instruction{
 		StartCallData:    uint64(len(cs.CallData)),
@@ -222,8 +410,3 @@ func (cs *System) compressHint(hm HintMapping, bID BlueprintID) Instruction {
 func (cs *System) GetNbConstraints() int {
 	return cs.NbConstraints
 }
-
-func (cs *System) CheckUnconstrainedWires() error {
-	// TODO @gbotrel
-	return nil
-}
@@ -0,0 +1,368 @@
+package cs
+
+// Symbol table protocol
+//
+// The symbol table is optional and serialized separately from the constraint system, such
+// that production systems don't carry it. It is bound to the system it describes by its
+// fingerprint. All integers are big-endian.
+//
+//	symbols   ->  [magic "\x89R1DB" | uint16(version) | [32]byte(fingerprint) | functions | locations | names | entries | mdebug]
+//	functions ->  [uint32(n) | n * (string(name) | string(systemName) | string(filename))]
+//	locations ->  [uint32(n) | n * (uint32(functionID) | uint64(line))]
+//	names     ->  [uint32(n) | n * (uint32(wireID) | string(name))], sorted by wireID
+//	entries   ->  [uint32(n) | n * (string(format) | uint32(m) | m * terms | ints(stack))]
+//	terms     ->  [uint32(n) | n * (uint32(coeffID) | uint32(wireID))]
+//	mdebug    ->  [uint32(n) | n * (uint32(instructionID) | uint32(entryID))], sorted by instructionID
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+const (
+	symbolTableMagic = "\x89R1DB"
+
+	// SymbolTableVersion is the version of the format written by WriteSymbolTableTo.
+	SymbolTableVersion = 1
+)
+
+// ErrSymbolTableMismatch is returned when loading a symbol table built for another
+// constraint system.
+var ErrSymbolTableMismatch = errors.New("symbol table doesn't match the constraint system")
+
+// Function is a function of the circuit source, referenced by a Location.
+type Function struct {
+	Name       string
+	SystemName string // fully qualified name
+	Filename   string
+}
+
+// Location is a line of the circuit source.
+type Location struct {
+	FunctionID int
+	Line       int64
+}
+
+// DebugEntry describes the origin of one or several instructions, as recorded by the
+// gnark frontend (see constraint.LogEntry upstream).
+//
+// Format contains a %s verb per expression of ToResolve, replaced by its value when
+// solving, followed by a %s verb for the stack.
+type DebugEntry struct {
+	Format    string
+	ToResolve []LinearExpression
+	Stack     []int // ids in Locations, innermost call first
+}
+
+// SymbolTable maps instructions to the circuit source locations and expressions they
+// originate from, and internal wires to names.
+type SymbolTable struct {
+	Functions []Function
+	Locations []Location
+
+	// Names of the internal wires, indexed by wire id. Public and secret wires are
+	// named in the constraint system.
+	Names map[int]string
+
+	// DebugInfo is indexed by MDebug, which maps instruction ids to entries.
+	DebugInfo []DebugEntry
+	MDebug    map[int]int
+}
+
+// NewSymbolTable returns an empty symbol table.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		Names:  make(map[int]string),
+		MDebug: make(map[int]int),
+	}
+}
+
+// AttachDebugInfo records entry as the origin of the given instructions. The symbol
+// table of the system is created if needed.
+func (system *System) AttachDebugInfo(entry DebugEntry, instructionIDs []int) {
+	if system.SymbolTable == nil {
+		system.SymbolTable = NewSymbolTable()
+	}
+	t := system.SymbolTable
+	t.DebugInfo = append(t.DebugInfo, entry)
+	for _, iID := range instructionIDs {
+		t.MDebug[iID] = len(t.DebugInfo) - 1
+	}
+}
+
+// debugEntry returns the debug entry of an instruction, if any.
+func (system *System) debugEntry(iID int) (*DebugEntry, bool) {
+	if system.SymbolTable == nil {
+		return nil, false
+	}
+	id, ok := system.SymbolTable.MDebug[iID]
+	if !ok {
+		return nil, false
+	}
+	return &system.SymbolTable.DebugInfo[id], true
+}
+
+// writeStack appends the source locations of the stack, one "function\n\tfile:line" per frame.
+func (t *SymbolTable) writeStack(sbb *strings.Builder, stack []int) {
+	for _, lID := range stack {
+		location := t.Locations[lID]
+		function := t.Functions[location.FunctionID]
+		sbb.WriteString(function.Name)
+		sbb.WriteString("\n\t")
+		sbb.WriteString(function.Filename)
+		sbb.WriteByte(':')
+		sbb.WriteString(strconv.FormatInt(location.Line, 10))
+		sbb.WriteByte('\n')
+	}
+}
+
+// source returns the innermost location of the stack as "function file:line", or "".
+func (t *SymbolTable) source(stack []int) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	location := t.Locations[stack[0]]
+	function := t.Functions[location.FunctionID]
+	return function.Name + " " + function.Filename + ":" + strconv.FormatInt(location.Line, 10)
+}
+
+// resolve formats the debug entry, evaluating its expressions with value. value returns
+// false if a wire is not solved, in which case the expression is rendered as <unsolved>.
+func (t *SymbolTable) resolve(entry *DebugEntry, coefficients []fr.Element, value func(Term) (fr.Element, bool)) string {
+	args := make([]any, 0, len(entry.ToResolve)+1)
+	for _, l := range entry.ToResolve {
+		var eval fr.Element
+		solved := true
+		for _, term := range l {
+			if term.IsConstant() {
+				eval.Add(&eval, &coefficients[term.CoeffID()])
+				continue
+			}
+			v, ok := value(term)
+			if !ok {
+				solved = false
+				break
+			}
+			eval.Add(&eval, &v)
+		}
+		if solved {
+			args = append(args, eval.String())
+		} else {
+			args = append(args, "<unsolved>")
+		}
+	}
+	var sbb strings.Builder
+	t.writeStack(&sbb, entry.Stack)
+	args = append(args, sbb.String())
+	return fmt.Sprintf(entry.Format, args...)
+}
+
+// symbolResolver resolves internal wire names from the symbol table, if any.
+type symbolResolver struct {
+	*system
+}
+
+func (r symbolResolver) VariableToString(vID int) string {
+	if r.SymbolTable != nil {
+		if name, ok := r.SymbolTable.Names[vID]; ok {
+			return name
+		}
+	}
+	return r.system.VariableToString(vID)
+}
+
+// WriteSymbolTableTo writes the symbol table of the system to w. It fails if the system
+// has no symbol table.
+func (cs *system) WriteSymbolTableTo(w io.Writer) (int64, error) {
+	t := cs.SymbolTable
+	if t == nil {
+		return 0, errors.New("constraint system has no symbol table")
+	}
+	_w := WriterCounter{W: w} // wraps writer to count the bytes written
+	enc := &encoder{w: bufio.NewWriterSize(&_w, 1<<16)}
+
+	enc.write([]byte(symbolTableMagic))
+	enc.writeUint16(SymbolTableVersion)
+	fingerprint := cs.Fingerprint()
+	enc.write(fingerprint[:])
+
+	enc.writeUint32(uint32(len(t.Functions)))
+	for _, f := range t.Functions {
+		enc.writeString(f.Name)
+		enc.writeString(f.SystemName)
+		enc.writeString(f.Filename)
+	}
+	enc.writeUint32(uint32(len(t.Locations)))
+	for _, l := range t.Locations {
+		enc.writeUint32(uint32(l.FunctionID))
+		enc.writeUint64(uint64(l.Line))
+	}
+
+	wireIDs := make([]int, 0, len(t.Names))
+	for wID := range t.Names {
+		wireIDs = append(wireIDs, wID)
+	}
+	sort.Ints(wireIDs)
+	enc.writeUint32(uint32(len(wireIDs)))
+	for _, wID := range wireIDs {
+		enc.writeUint32(uint32(wID))
+		enc.writeString(t.Names[wID])
+	}
+
+	enc.writeUint32(uint32(len(t.DebugInfo)))
+	for _, entry := range t.DebugInfo {
+		enc.writeString(entry.Format)
+		enc.writeUint32(uint32(len(entry.ToResolve)))
+		for _, l := range entry.ToResolve {
+			enc.writeUint32(uint32(len(l)))
+			for _, term := range l {
+				enc.writeUint32(term.CID)
+				enc.writeUint32(term.VID)
+			}
+		}
+		enc.writeInts(entry.Stack)
+	}
+
+	iIDs := make([]int, 0, len(t.MDebug))
+	for iID := range t.MDebug {
+		iIDs = append(iIDs, iID)
+	}
+	sort.Ints(iIDs)
+	enc.writeUint32(uint32(len(iIDs)))
+	for _, iID := range iIDs {
+		enc.writeUint32(uint32(iID))
+		enc.writeUint32(uint32(t.MDebug[iID]))
+	}
+
+	if enc.err == nil {
+		enc.err = enc.w.Flush()
+	}
+	return _w.N, enc.err
+}
+
+// ReadSymbolTableFrom reads a symbol table written by WriteSymbolTableTo and attaches it
+// to the system, replacing the existing one. It returns ErrSymbolTableMismatch if the
+// table was written for a system with a different fingerprint.
+func (cs *system) ReadSymbolTableFrom(r io.Reader) (int64, error) {
+	_r := ReaderCounter{R: r} // wraps reader to count the bytes read
+	dec := &decoder{r: bufio.NewReaderSize(&_r, 1<<16)}
+
+	magic := make([]byte, len(symbolTableMagic))
+	dec.read(magic)
+	if dec.err != nil {
+		return _r.N, dec.err
+	}
+	if !bytes.Equal(magic, []byte(symbolTableMagic)) {
+		return _r.N, fmt.Errorf("%w: not a symbol table", ErrInvalidEncoding)
+	}
+	if version := dec.readUint16(); dec.err == nil && version != SymbolTableVersion {
+		return _r.N, fmt.Errorf("unsupported symbol table version %d", version)
+	}
+	var fingerprint Fingerprint
+	dec.read(fingerprint[:])
+	if dec.err == nil && fingerprint != cs.Fingerprint() {
+		return _r.N, ErrSymbolTableMismatch
+	}
+
+	t := NewSymbolTable()
+	n := dec.readUint32()
+	t.Functions = make([]Function, 0, capAlloc(uint64(n)))
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		t.Functions = append(t.Functions, Function{
+			Name:       dec.readString(),
+			SystemName: dec.readString(),
+			Filename:   dec.readString(),
+		})
+	}
+	n = dec.readUint32()
+	t.Locations = make([]Location, 0, capAlloc(uint64(n)))
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		t.Locations = append(t.Locations, Location{
+			FunctionID: int(dec.readUint32()),
+			Line:       int64(dec.readUint64()),
+		})
+	}
+	n = dec.readUint32()
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		wID := int(dec.readUint32())
+		t.Names[wID] = dec.readString()
+	}
+	n = dec.readUint32()
+	t.DebugInfo = make([]DebugEntry, 0, capAlloc(uint64(n)))
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		entry := DebugEntry{Format: dec.readString()}
+		m := dec.readUint32()
+		entry.ToResolve = make([]LinearExpression, 0, capAlloc(uint64(m)))
+		for j := uint32(0); j < m && dec.err == nil; j++ {
+			nbTerms := dec.readUint32()
+			l := make(LinearExpression, 0, capAlloc(uint64(nbTerms)))
+			for k := uint32(0); k < nbTerms && dec.err == nil; k++ {
+				l = append(l, Term{CID: dec.readUint32(), VID: dec.readUint32()})
+			}
+			entry.ToResolve = append(entry.ToResolve, l)
+		}
+		entry.Stack = dec.readInts()
+		t.DebugInfo = append(t.DebugInfo, entry)
+	}
+	n = dec.readUint32()
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		iID := int(dec.readUint32())
+		t.MDebug[iID] = int(dec.readUint32())
+	}
+	if dec.err != nil {
+		return _r.N, dec.err
+	}
+
+	if err := cs.checkSymbolTable(t); err != nil {
+		return _r.N, err
+	}
+	cs.SymbolTable = t
+	return _r.N, nil
+}
+
+// checkSymbolTable ensures the symbol table only references existing instructions, wires,
+// coefficients and locations, such that it can be used safely by the solver.
+func (cs *system) checkSymbolTable(t *SymbolTable) error {
+	nbWires := len(cs.Public) + len(cs.Secret) + cs.NbInternalVariables
+	for _, l := range t.Locations {
+		if l.FunctionID < 0 || l.FunctionID >= len(t.Functions) {
+			return fmt.Errorf("%w: location references unknown function %d", ErrInvalidEncoding, l.FunctionID)
+		}
+	}
+	for wID := range t.Names {
+		if wID < 0 || wID >= nbWires {
+			return fmt.Errorf("%w: name of unknown wire %d", ErrInvalidEncoding, wID)
+		}
+	}
+	for i, entry := range t.DebugInfo {
+		for _, l := range entry.ToResolve {
+			for _, term := range l {
+				if term.CoeffID() >= len(cs.Coefficients) || (!term.IsConstant() && term.WireID() >= nbWires) {
+					return fmt.Errorf("%w: debug entry %d references unknown term", ErrInvalidEncoding, i)
+				}
+			}
+		}
+		for _, lID := range entry.Stack {
+			if lID < 0 || lID >= len(t.Locations) {
+				return fmt.Errorf("%w: debug entry %d references unknown location %d", ErrInvalidEncoding, i, lID)
+			}
+		}
+	}
+	for iID, id := range t.MDebug {
+		if iID < 0 || iID >= len(cs.Instructions) {
+			return fmt.Errorf("%w: debug info of unknown instruction %d", ErrInvalidEncoding, iID)
+		}
+		if id < 0 || id >= len(t.DebugInfo) {
+			return fmt.Errorf("%w: unknown debug entry %d", ErrInvalidEncoding, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package cs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff lists the differences between two constraint systems, as computed by DiffR1CS.
+// Fields are empty (or nil) when both systems agree.
+type Diff struct {
+	ScalarField []string `json:"scalarField,omitempty"` // [a, b]
+
+	PublicAdded   []string `json:"publicAdded,omitempty"`
+	PublicRemoved []string `json:"publicRemoved,omitempty"`
+	SecretAdded   []string `json:"secretAdded,omitempty"`
+	SecretRemoved []string `json:"secretRemoved,omitempty"`
+	// InputsReordered is set if both systems have the same inputs in a different order.
+	InputsReordered bool `json:"inputsReordered,omitempty"`
+
+	NbConstraints       []int `json:"nbConstraints,omitempty"`       // [a, b]
+	NbInternalVariables []int `json:"nbInternalVariables,omitempty"` // [a, b]
+
+	// Blueprints lists the blueprints which number of instructions differ.
+	Blueprints []BlueprintCount `json:"blueprints,omitempty"`
+
+	HintsAdded   []string `json:"hintsAdded,omitempty"`
+	HintsRemoved []string `json:"hintsRemoved,omitempty"`
+
+	Commitment []Commitment `json:"commitment,omitempty"` // [a, b]
+
+	// Constraints lists the first constraints of a system which are not in the other,
+	// formatted with R1C.String.
+	Constraints []ConstraintDiff `json:"constraints,omitempty"`
+	// ConstraintsReordered is set if both systems have the same constraints in a different
+	// order.
+	ConstraintsReordered bool `json:"constraintsReordered,omitempty"`
+}
+
+// BlueprintCount is the number of instructions using a blueprint in each system.
+type BlueprintCount struct {
+	Name string `json:"name"`
+	A    int    `json:"a"`
+	B    int    `json:"b"`
+}
+
+// ConstraintDiff is a constraint of a system which is not in the other: A is empty if the
+// constraint was added in b, B is empty if it was removed from a. Constraint is its id in
+// the system it belongs to.
+type ConstraintDiff struct {
+	Constraint int    `json:"constraint"`
+	A          string `json:"a"`
+	B          string `json:"b"`
+}
+
+// Equal returns true if no difference was found.
+func (d *Diff) Equal() bool {
+	return reflect.DeepEqual(d, &Diff{})
+}
+
+// DiffR1CS compares two constraint systems and reports differences in inputs, constraint
+// counts per blueprint, hint dependencies, commitment info and up to maxConstraints
+// constraints of a system which are not in the other.
+//
+// Constraints are compared with wire names and coefficient values, such that systems with
+// different coefficient tables or calldata layouts (e.g. after CompactBlueprints) can be
+// compared, and regardless of the order of their terms and of L and R. They are matched
+// regardless of their position: a constraint moved is not reported, but
+// ConstraintsReordered is set if all constraints match in a different order. Internal wires
+// are named after their id, so systems with internal wires renumbered differ.
+func DiffR1CS(a, b *R1CS, maxConstraints int) *Diff {
+	d := &Diff{}
+
+	if a.ScalarField != b.ScalarField {
+		d.ScalarField = []string{a.ScalarField, b.ScalarField}
+	}
+
+	d.PublicAdded, d.PublicRemoved = diffNames(a.Public, b.Public)
+	d.SecretAdded, d.SecretRemoved = diffNames(a.Secret, b.Secret)
+	if len(d.PublicAdded)+len(d.PublicRemoved)+len(d.SecretAdded)+len(d.SecretRemoved) == 0 {
+		d.InputsReordered = !reflect.DeepEqual(a.Public, b.Public) || !reflect.DeepEqual(a.Secret, b.Secret)
+	}
+
+	if a.GetNbConstraints() != b.GetNbConstraints() {
+		d.NbConstraints = []int{a.GetNbConstraints(), b.GetNbConstraints()}
+	}
+	if a.NbInternalVariables != b.NbInternalVariables {
+		d.NbInternalVariables = []int{a.NbInternalVariables, b.NbInternalVariables}
+	}
+
+	countA, countB := a.blueprintUsage(), b.blueprintUsage()
+	for name := range countB {
+		if _, ok := countA[name]; !ok {
+			countA[name] = 0
+		}
+	}
+	for name, n := range countA {
+		if n != countB[name] {
+			d.Blueprints = append(d.Blueprints, BlueprintCount{Name: name, A: n, B: countB[name]})
+		}
+	}
+	sort.Slice(d.Blueprints, func(i, j int) bool { return d.Blueprints[i].Name < d.Blueprints[j].Name })
+
+	hintsA := make([]string, 0, len(a.MHintsDependencies))
+	for _, name := range a.MHintsDependencies {
+		hintsA = append(hintsA, name)
+	}
+	hintsB := make([]string, 0, len(b.MHintsDependencies))
+	for _, name := range b.MHintsDependencies {
+		hintsB = append(hintsB, name)
+	}
+	d.HintsAdded, d.HintsRemoved = diffNames(hintsA, hintsB)
+	sort.Strings(d.HintsAdded)
+	sort.Strings(d.HintsRemoved)
+
+	if !reflect.DeepEqual(a.CommitmentInfo, b.CommitmentInfo) {
+		d.Commitment = []Commitment{a.CommitmentInfo, b.CommitmentInfo}
+	}
+
+	if maxConstraints > 0 {
+		d.diffConstraints(a, b, maxConstraints)
+	}
+
+	return d
+}
+
+// String formats the differences, one per line.
+func (d *Diff) String() string {
+	var sbb strings.Builder
+	if d.ScalarField != nil {
+		fmt.Fprintf(&sbb, "scalar field: %s != %s\n", d.ScalarField[0], d.ScalarField[1])
+	}
+	writeNames := func(title string, names []string) {
+		if len(names) != 0 {
+			fmt.Fprintf(&sbb, "%s: %s\n", title, strings.Join(names, ", "))
+		}
+	}
+	writeNames("public inputs added", d.PublicAdded)
+	writeNames("public inputs removed", d.PublicRemoved)
+	writeNames("secret inputs added", d.SecretAdded)
+	writeNames("secret inputs removed", d.SecretRemoved)
+	if d.InputsReordered {
+		sbb.WriteString("inputs reordered\n")
+	}
+	if d.NbConstraints != nil {
+		fmt.Fprintf(&sbb, "constraints: %d -> %d\n", d.NbConstraints[0], d.NbConstraints[1])
+	}
+	if d.NbInternalVariables != nil {
+		fmt.Fprintf(&sbb, "internal variables: %d -> %d\n", d.NbInternalVariables[0], d.NbInternalVariables[1])
+	}
+	for _, b := range d.Blueprints {
+		fmt.Fprintf(&sbb, "blueprint %s: %d -> %d instructions\n", b.Name, b.A, b.B)
+	}
+	writeNames("hints added", d.HintsAdded)
+	writeNames("hints removed", d.HintsRemoved)
+	if d.Commitment != nil {
+		fmt.Fprintf(&sbb, "commitment: %+v -> %+v\n", d.Commitment[0], d.Commitment[1])
+	}
+	if d.ConstraintsReordered {
+		sbb.WriteString("constraints reordered\n")
+	}
+	for _, c := range d.Constraints {
+		if c.A != "" {
+			fmt.Fprintf(&sbb, "- constraint #%d: %s\n", c.Constraint, c.A)
+		} else {
+			fmt.Fprintf(&sbb, "+ constraint #%d: %s\n", c.Constraint, c.B)
+		}
+	}
+	return sbb.String()
+}
+
+// diffNames returns the names of b not in a, and the names of a not in b.
+func diffNames(a, b []string) (added, removed []string) {
+	inA := make(map[string]struct{}, len(a))
+	for _, name := range a {
+		inA[name] = struct{}{}
+	}
+	inB := make(map[string]struct{}, len(b))
+	for _, name := range b {
+		inB[name] = struct{}{}
+		if _, ok := inA[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for _, name := range a {
+		if _, ok := inB[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	return
+}
+
+// blueprintUsage returns the number of instructions per blueprint name.
+func (system *System) blueprintUsage() map[string]int {
+	names := make([]string, len(system.Blueprints))
+	for i, b := range system.Blueprints {
+		names[i] = BlueprintName(b)
+	}
+	usage := make(map[string]int, len(names))
+	for _, inst := range system.Instructions {
+		usage[names[inst.BlueprintID]]++
+	}
+	return usage
+}
+
+// diffConstraints reports up to max constraints of a not in b and of b not in a, matched by
+// canonical key as a multiset, ordered by constraint id.
+func (d *Diff) diffConstraints(a, b *R1CS, max int) {
+	keysA, keysB := a.constraintKeys(), b.constraintKeys()
+	countA := make(map[string]int, len(keysA))
+	for _, c := range keysA {
+		countA[c.key]++
+	}
+	countB := make(map[string]int, len(keysB))
+	for _, c := range keysB {
+		countB[c.key]++
+	}
+
+	var removed, added []ConstraintDiff
+	for _, c := range keysA {
+		if countB[c.key] > 0 {
+			countB[c.key]--
+		} else if len(removed) < max {
+			removed = append(removed, ConstraintDiff{Constraint: c.cID, A: a.constraintString(c.iID)})
+		}
+	}
+	for _, c := range keysB {
+		if countA[c.key] > 0 {
+			countA[c.key]--
+		} else if len(added) < max {
+			added = append(added, ConstraintDiff{Constraint: c.cID, B: b.constraintString(c.iID)})
+		}
+	}
+
+	if len(removed)+len(added) == 0 {
+		for i := range keysA {
+			if keysA[i].key != keysB[i].key {
+				d.ConstraintsReordered = true
+				break
+			}
+		}
+		return
+	}
+	d.Constraints = append(removed, added...)
+	sort.SliceStable(d.Constraints, func(i, j int) bool { return d.Constraints[i].Constraint < d.Constraints[j].Constraint })
+	if len(d.Constraints) > max {
+		d.Constraints = d.Constraints[:max]
+	}
+}
+
+// constraintKey is the canonical key of the R1C of an instruction.
+type constraintKey struct {
+	key string
+	iID int // instruction id
+	cID int // constraint id
+}
+
+// constraintKeys returns the canonical keys of the R1C of the system, with wire names.
+func (cs *system) constraintKeys() []constraintKey {
+	var (
+		r1c  R1C
+		keys []constraintKey
+	)
+	wireKey := func(vID uint32) string { return cs.VariableToString(int(vID)) }
+	for iID, inst := range cs.Instructions {
+		if bc, ok := cs.Blueprints[inst.BlueprintID].(BlueprintR1C); ok {
+			bc.DecompressR1C(&r1c, cs.GetCallData(inst))
+			keys = append(keys, constraintKey{key: cs.canonicalR1C(&r1c, wireKey), iID: iID, cID: int(inst.ConstraintOffset)})
+		}
+	}
+	return keys
+}
+
+// constraintString returns the string representation of the R1C of an instruction.
+func (cs *system) constraintString(iID int) string {
+	var r1c R1C
+	inst := cs.Instructions[iID]
+	cs.Blueprints[inst.BlueprintID].(BlueprintR1C).DecompressR1C(&r1c, cs.GetCallData(inst))
+	return r1c.String(cs)
+}
@@ -0,0 +1,761 @@
+package cs
+
+// Binary protocol
+//
+// R1CS are serialized with a versioned binary format, faster to decode than gob and
+// independent of gob type registration. All integers are big-endian.
+//
+//	R1CS         ->  [header | names | blueprints | hints | instructions | calldata | coefficients | levels | commitment | string(gnark version)]
+//	header       ->  [magic "\x89R1CS" | uint16(version) | uint16(type) | bytes(scalar field) | counts]
+//	counts       ->  [uint64(nbInstructions) | uint64(len(calldata)) | uint64(nbCoefficients) | uint64(nbConstraints) | uint64(nbInternalVariables)]
+//	names        ->  [strings(public) | strings(secret)]
+//	blueprints   ->  [uint32(n) | n * (string(registered name) | bytes(payload))]
+//	hints        ->  [uint32(n) | n * (uint32(hintID) | string(name))], sorted by hintID
+//	instructions ->  nbInstructions * [uint32(blueprintID) | uint32(constraintOffset) | uint64(startCallData)]
+//	calldata     ->  len(calldata) * uint32
+//	coefficients ->  nbCoefficients * [32]byte, canonical big-endian field elements
+//	levels       ->  [uint32(nbLevels) | nbLevels * (uint32(n) | n * uint32(instructionID))]
+//	commitment   ->  [uint8(0)] or [uint8(1) | ints(committed) | uint32(nbPrivateCommitted) | uint32(hintID) | uint32(commitmentIndex) | ints(committedAndCommitment)]
+//
+//	bytes        ->  [uint32(len) | len * byte]
+//	string       ->  bytes
+//	strings      ->  [uint32(n) | n * string]
+//	ints         ->  [uint32(n) | n * uint32]
+//
+// Blueprints are identified by their name in the blueprint registry (see RegisterBlueprint);
+// their payload is empty unless they implement encoding.BinaryMarshaler.
+//
+// Version 1 has no gnark version; it is still decoded.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+const (
+	binaryMagic = "\x89R1CS"
+
+	// BinaryVersion is the version of the binary format written by WriteTo.
+	BinaryVersion = 2
+
+	// maxDecodeAlloc bounds the size of the slices allocated upfront by the decoder,
+	// larger slices grow as the data is actually read.
+	maxDecodeAlloc = 1 << 20
+
+	// maxDecodeWires bounds the number of wires of a decoded system, allocated by the
+	// solver upfront. bn254 FFT domains are bounded to 2^28 constraints.
+	maxDecodeWires = 1 << 28
+)
+
+// ErrInvalidEncoding is returned when decoding a malformed constraint system.
+var ErrInvalidEncoding = errors.New("invalid constraint system encoding")
+
+// WriteTo encodes R1CS into provided io.Writer using the versioned binary format
+func (cs *system) WriteTo(w io.Writer) (int64, error) {
+	_w := WriterCounter{W: w} // wraps writer to count the bytes written
+	enc := &encoder{w: bufio.NewWriterSize(&_w, 1<<16)}
+
+	enc.writeHeader(cs)
+	enc.writeStrings(cs.Public)
+	enc.writeStrings(cs.Secret)
+
+	enc.writeUint32(uint32(len(cs.Blueprints)))
+	for _, b := range cs.Blueprints {
+		enc.writeString(BlueprintName(b))
+		var payload []byte
+		if m, ok := b.(encoding.BinaryMarshaler); ok && enc.err == nil {
+			payload, enc.err = m.MarshalBinary()
+		}
+		enc.writeBytes(payload)
+	}
+
+	hintIDs := make([]hintsolver.HintID, 0, len(cs.MHintsDependencies))
+	for id := range cs.MHintsDependencies {
+		hintIDs = append(hintIDs, id)
+	}
+	sort.Slice(hintIDs, func(i, j int) bool { return hintIDs[i] < hintIDs[j] })
+	enc.writeUint32(uint32(len(hintIDs)))
+	for _, id := range hintIDs {
+		enc.writeUint32(uint32(id))
+		enc.writeString(cs.MHintsDependencies[id])
+	}
+
+	for _, inst := range cs.Instructions {
+		enc.writeUint32(uint32(inst.BlueprintID))
+		enc.writeUint32(inst.ConstraintOffset)
+		enc.writeUint64(inst.StartCallData)
+	}
+	for _, v := range cs.CallData {
+		enc.writeUint32(v)
+	}
+	for i := range cs.Coefficients {
+		b := cs.Coefficients[i].Bytes()
+		enc.write(b[:])
+	}
+
+	enc.writeUint32(uint32(len(cs.Levels)))
+	for _, level := range cs.Levels {
+		enc.writeInts(level)
+	}
+
+	if cs.CommitmentInfo.Is() {
+		enc.write([]byte{1})
+		enc.writeInts(cs.CommitmentInfo.Committed)
+		enc.writeUint32(uint32(cs.CommitmentInfo.NbPrivateCommitted))
+		enc.writeUint32(uint32(cs.CommitmentInfo.HintID))
+		enc.writeUint32(uint32(cs.CommitmentInfo.CommitmentIndex))
+		enc.writeInts(cs.CommitmentInfo.CommittedAndCommitment)
+	} else {
+		enc.write([]byte{0})
+	}
+	enc.writeString(cs.GnarkVersion)
+
+	if enc.err == nil {
+		enc.err = enc.w.Flush()
+	}
+	return _w.N, enc.err
+}
+
+// ReadFrom attempts to decode R1CS from io.Reader.
+// It accepts the versioned binary format written by WriteTo, the legacy gob encoding and
+// the upstream gnark encoding (see ReadGnarkFrom).
+func (cs *system) ReadFrom(r io.Reader) (int64, error) {
+	_r := ReaderCounter{R: r} // wraps reader to count the bytes read
+	br := bufio.NewReaderSize(&_r, 1<<16)
+
+	magic, err := br.Peek(len(binaryMagic))
+	if err != nil && len(magic) == 0 {
+		return _r.N, err
+	}
+	if isGnarkEncoding(magic[0]) {
+		_, err = cs.ReadGnarkFrom(br)
+		return _r.N, err
+	}
+	if !bytes.Equal(magic, []byte(binaryMagic)) {
+		err = cs.readGob(br)
+		return _r.N, err
+	}
+
+	dec := &decoder{r: br}
+	err = cs.readBinary(dec)
+	return _r.N, err
+}
+
+// WriteGobTo encodes R1CS into provided io.Writer using the legacy gob encoding
+func (cs *system) WriteGobTo(w io.Writer) (int64, error) {
+	_w := WriterCounter{W: w} // wraps writer to count the bytes written
+
+	// encode our object
+	encoder := gob.NewEncoder(&_w)
+
+	return _w.N, encoder.Encode(cs)
+}
+
+// ConvertGob decodes a R1CS encoded with the legacy gob encoding (or any encoding accepted
+// by ReadFrom) from r and writes it to w using the versioned binary format.
+func ConvertGob(r io.Reader, w io.Writer) error {
+	var ccs R1CS
+	if _, err := ccs.ReadFrom(r); err != nil {
+		return err
+	}
+	_, err := ccs.WriteTo(w)
+	return err
+}
+
+func (cs *system) readGob(r io.Reader) error {
+	decoder := gob.NewDecoder(r)
+
+	// the coeff table index and the builder state are not serialized,
+	// they are rebuilt on demand if the system is extended after decoding.
+	cs.CoeffTable = CoeffTable{}
+	cs.System.lbWireLevel = nil
+
+	if err := decoder.Decode(cs); err != nil {
+		return err
+	}
+
+	if err := cs.CheckSerializationHeader(); err != nil {
+		return err
+	}
+	return cs.checkInstructions()
+}
+
+func (cs *system) readBinary(dec *decoder) error {
+	*cs = system{}
+
+	if _, err := dec.r.Discard(len(binaryMagic)); err != nil {
+		return err
+	}
+	version := dec.readUint16()
+	if dec.err == nil && (version == 0 || version > BinaryVersion) {
+		return fmt.Errorf("unsupported constraint system encoding version %d", version)
+	}
+	cs.Type = int(dec.readUint16())
+	q := new(big.Int).SetBytes(dec.readBytes())
+	cs.ScalarField = q.Text(16)
+
+	nbInstructions := dec.readCount()
+	nbCallData := dec.readCount()
+	nbCoefficients := dec.readCount()
+	cs.NbConstraints = int(dec.readCount())
+	cs.NbInternalVariables = int(dec.readCount())
+
+	cs.Public = dec.readStrings()
+	cs.Secret = dec.readStrings()
+
+	nbBlueprints := dec.readUint32()
+	for i := uint32(0); i < nbBlueprints && dec.err == nil; i++ {
+		name := dec.readString()
+		payload := dec.readBytes()
+		if dec.err != nil {
+			break
+		}
+		b, err := NewBlueprint(name)
+		if err != nil {
+			return err
+		}
+		if len(payload) != 0 {
+			u, ok := b.(encoding.BinaryUnmarshaler)
+			if !ok {
+				return fmt.Errorf("%w: blueprint %s has a payload but can't unmarshal it", ErrInvalidEncoding, name)
+			}
+			if err := u.UnmarshalBinary(payload); err != nil {
+				return err
+			}
+		}
+		cs.Blueprints = append(cs.Blueprints, b)
+	}
+
+	nbHints := dec.readUint32()
+	cs.MHintsDependencies = make(map[hintsolver.HintID]string, capAlloc(uint64(nbHints)))
+	for i := uint32(0); i < nbHints && dec.err == nil; i++ {
+		id := hintsolver.HintID(dec.readUint32())
+		cs.MHintsDependencies[id] = dec.readString()
+	}
+
+	cs.Instructions = make([]Instruction, 0, capAlloc(nbInstructions))
+	for i := uint64(0); i < nbInstructions && dec.err == nil; i++ {
+		var inst Instruction
+		inst.BlueprintID = BlueprintID(dec.readUint32())
+		inst.ConstraintOffset = dec.readUint32()
+		inst.StartCallData = dec.readUint64()
+		cs.Instructions = append(cs.Instructions, inst)
+	}
+
+	cs.CallData = dec.readUint32s(nbCallData)
+
+	cs.Coefficients = make([]fr.Element, 0, capAlloc(nbCoefficients))
+	var buf [fr.Bytes]byte
+	for i := uint64(0); i < nbCoefficients && dec.err == nil; i++ {
+		dec.read(buf[:])
+		var e fr.Element
+		if err := e.SetBytesCanonical(buf[:]); err != nil && dec.err == nil {
+			return fmt.Errorf("%w: coefficient %d: %s", ErrInvalidEncoding, i, err)
+		}
+		cs.Coefficients = append(cs.Coefficients, e)
+	}
+
+	nbLevels := dec.readUint32()
+	cs.Levels = make([][]int, 0, capAlloc(uint64(nbLevels)))
+	for i := uint32(0); i < nbLevels && dec.err == nil; i++ {
+		cs.Levels = append(cs.Levels, dec.readInts())
+	}
+
+	var present [1]byte
+	dec.read(present[:])
+	switch present[0] {
+	case 0:
+	case 1:
+		cs.CommitmentInfo.Committed = dec.readInts()
+		cs.CommitmentInfo.NbPrivateCommitted = int(dec.readUint32())
+		cs.CommitmentInfo.HintID = hintsolver.HintID(dec.readUint32())
+		cs.CommitmentInfo.CommitmentIndex = int(dec.readUint32())
+		cs.CommitmentInfo.CommittedAndCommitment = dec.readInts()
+	default:
+		return fmt.Errorf("%w: commitment flag %d", ErrInvalidEncoding, present[0])
+	}
+	if version >= 2 {
+		cs.GnarkVersion = dec.readString()
+	}
+
+	if dec.err != nil {
+		if dec.err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return dec.err
+	}
+
+	if err := cs.CheckSerializationHeader(); err != nil {
+		return err
+	}
+	return cs.checkInstructions()
+}
+
+// checkInstructions ensures a decoded system is well-formed, such that it can be walked and
+// solved safely:
+//   - instructions reference existing blueprints and calldata, and their calldata is
+//     consistent with the layout of their blueprint;
+//   - constraints and hints reference existing coefficients, wires and constraints;
+//   - the levels cover each instruction exactly once, and solving them in order
+//     instantiates each wire exactly once (see checkSolvingOrder);
+//   - the commitment references existing wires.
+//
+// Instructions of custom blueprints that don't expose their wires (see BlueprintWires)
+// are trusted, and the solving order isn't checked if the system has some.
+func (cs *system) checkInstructions() error {
+	if cs.Type != ConstrainSystemTypeR1CS {
+		return fmt.Errorf("%w: unsupported constraint system type %d", ErrInvalidEncoding, cs.Type)
+	}
+	if len(cs.Coefficients) <= CoeffIdMinusTwo {
+		return fmt.Errorf("%w: missing default coefficients", ErrInvalidEncoding)
+	}
+	nbInputs := len(cs.Public) + len(cs.Secret)
+	if cs.NbConstraints < 0 {
+		return fmt.Errorf("%w: %d constraints", ErrInvalidEncoding, cs.NbConstraints)
+	}
+	if cs.NbInternalVariables < 0 || uint64(nbInputs)+uint64(cs.NbInternalVariables) > maxDecodeWires {
+		return fmt.Errorf("%w: %d internal variables", ErrInvalidEncoding, cs.NbInternalVariables)
+	}
+	nbWires := nbInputs + cs.NbInternalVariables
+
+	checkTerms := func(l LinearExpression, allowConstant bool) error {
+		for _, t := range l {
+			if int(t.CID) >= len(cs.Coefficients) {
+				return fmt.Errorf("unknown coefficient %d", t.CID)
+			}
+			if t.VID >= uint32(nbWires) && !(allowConstant && t.IsConstant()) {
+				return fmt.Errorf("unknown wire %d", t.VID)
+			}
+		}
+		return nil
+	}
+
+	var (
+		r1c           R1C
+		hm            HintMapping
+		nbConstraints uint64
+		nbSolvable    = uint64(nbInputs) // upper bound of the number of wires the instructions solve
+		opaque        bool
+	)
+	for i, inst := range cs.Instructions {
+		if int(inst.BlueprintID) >= len(cs.Blueprints) || cs.Blueprints[inst.BlueprintID] == nil {
+			return fmt.Errorf("%w: instruction %d: unknown blueprint %d", ErrInvalidEncoding, i, inst.BlueprintID)
+		}
+		if inst.StartCallData >= uint64(len(cs.CallData)) {
+			return fmt.Errorf("%w: instruction %d: calldata out of range", ErrInvalidEncoding, i)
+		}
+		blueprint := cs.Blueprints[inst.BlueprintID]
+		nbInputs := blueprint.NbInputs()
+		if nbInputs < 0 {
+			nbInputs = int(cs.CallData[inst.StartCallData])
+		}
+		if inst.StartCallData+uint64(nbInputs) > uint64(len(cs.CallData)) {
+			return fmt.Errorf("%w: instruction %d: calldata out of range", ErrInvalidEncoding, i)
+		}
+		calldata := cs.GetCallData(inst)
+		if c, ok := blueprint.(calldataChecker); ok {
+			if err := c.checkCallData(calldata); err != nil {
+				return fmt.Errorf("%w: instruction %d: %s", ErrInvalidEncoding, i, err)
+			}
+		}
+
+		n := uint64(blueprint.NbConstraints())
+		if uint64(inst.ConstraintOffset)+n > uint64(cs.NbConstraints) {
+			return fmt.Errorf("%w: instruction %d: constraint %d out of range", ErrInvalidEncoding, i, inst.ConstraintOffset)
+		}
+		nbConstraints += n
+
+		var err error
+		switch bc := blueprint.(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, calldata)
+			for _, l := range []LinearExpression{r1c.L, r1c.R, r1c.O} {
+				if err == nil {
+					err = checkTerms(l, false)
+				}
+			}
+			nbSolvable += uint64(len(r1c.L) + len(r1c.R) + len(r1c.O))
+		case BlueprintHint:
+			bc.DecompressHint(&hm, calldata)
+			for _, l := range hm.Inputs {
+				if err == nil {
+					err = checkTerms(l, true)
+				}
+			}
+			start, end := hm.OutputRange.Start, hm.OutputRange.End
+			if err == nil && (start < uint32(len(cs.Public)+len(cs.Secret)) || start > end || end > uint32(nbWires)) {
+				err = fmt.Errorf("hint outputs [%d, %d) out of range", start, end)
+			}
+			nbSolvable += uint64(end - start)
+		case BlueprintWires:
+			for it := chainWires(bc.InputWires(calldata), bc.OutputWires(calldata)); err == nil; {
+				wID := it()
+				if wID == -1 {
+					break
+				}
+				if wID >= nbWires {
+					err = fmt.Errorf("unknown wire %d", wID)
+				}
+			}
+			out := bc.OutputWires(calldata)
+			for wID := out(); wID != -1; wID = out() {
+				nbSolvable++
+			}
+		default:
+			opaque = true
+		}
+		if err != nil {
+			return fmt.Errorf("%w: instruction %d: %s", ErrInvalidEncoding, i, err)
+		}
+	}
+	if nbConstraints != uint64(cs.NbConstraints) {
+		return fmt.Errorf("%w: %d constraints, instructions define %d", ErrInvalidEncoding, cs.NbConstraints, nbConstraints)
+	}
+	if !opaque && uint64(nbWires) > nbSolvable {
+		return fmt.Errorf("%w: %d wires, instructions solve at most %d", ErrInvalidEncoding, nbWires, nbSolvable)
+	}
+
+	seen := make([]bool, len(cs.Instructions))
+	nbSeen := 0
+	for _, level := range cs.Levels {
+		for _, iID := range level {
+			if iID < 0 || iID >= len(cs.Instructions) {
+				return fmt.Errorf("%w: level references unknown instruction %d", ErrInvalidEncoding, iID)
+			}
+			if seen[iID] {
+				return fmt.Errorf("%w: instruction %d is in several levels", ErrInvalidEncoding, iID)
+			}
+			seen[iID] = true
+			nbSeen++
+		}
+	}
+	if nbSeen != len(cs.Instructions) {
+		return fmt.Errorf("%w: %d instructions are not in any level", ErrInvalidEncoding, len(cs.Instructions)-nbSeen)
+	}
+
+	if c := &cs.CommitmentInfo; c.Is() {
+		if c.CommitmentIndex <= 0 || c.CommitmentIndex >= nbWires {
+			return fmt.Errorf("%w: commitment wire %d out of range", ErrInvalidEncoding, c.CommitmentIndex)
+		}
+		if c.NbPrivateCommitted < 0 || c.NbPrivateCommitted > len(c.Committed) {
+			return fmt.Errorf("%w: %d private committed wires out of %d", ErrInvalidEncoding, c.NbPrivateCommitted, len(c.Committed))
+		}
+		for _, wID := range c.Committed {
+			if wID < 0 || wID >= nbWires {
+				return fmt.Errorf("%w: commitment references unknown wire %d", ErrInvalidEncoding, wID)
+			}
+		}
+	}
+	for _, wID := range cs.CommitmentInfo.CommittedAndCommitment {
+		if wID < 0 || wID >= nbWires {
+			return fmt.Errorf("%w: commitment references unknown wire %d", ErrInvalidEncoding, wID)
+		}
+	}
+	if cs.SymbolTable != nil {
+		if err := cs.checkSymbolTable(cs.SymbolTable); err != nil {
+			return err
+		}
+	}
+
+	if opaque {
+		return nil
+	}
+	return cs.checkSolvingOrder(nbWires)
+}
+
+// checkSolvingOrder walks the levels as the solver does, and ensures each instruction
+// reads solved wires only and solves each wire once, and each constraint has at most one
+// wire to solve. In a level solved in parallel (more than minWorkPerCPU instructions), the
+// wires solved by an instruction can't be read by the other instructions of the level.
+func (cs *system) checkSolvingOrder(nbWires int) error {
+	solved := make([]bool, nbWires)
+	inLevel := make([]bool, nbWires) // solved in the current parallel level
+	for i := 0; i < len(cs.Public)+len(cs.Secret); i++ {
+		solved[i] = true
+	}
+	nbSolved := len(cs.Public) + len(cs.Secret)
+
+	var (
+		r1c     R1C
+		hm      HintMapping
+		pending []int
+	)
+	for _, level := range cs.Levels {
+		parallel := float64(len(level))/minWorkPerCPU > 1.0
+		isSolved := func(wID int) bool {
+			return solved[wID] && !inLevel[wID]
+		}
+		solve := func(wID int) error {
+			if solved[wID] {
+				return fmt.Errorf("wire %d is solved twice", wID)
+			}
+			solved[wID] = true
+			nbSolved++
+			if parallel {
+				inLevel[wID] = true
+				pending = append(pending, wID)
+			}
+			return nil
+		}
+
+		for _, iID := range level {
+			inst := cs.Instructions[iID]
+			blueprint := cs.Blueprints[inst.BlueprintID]
+			calldata := cs.GetCallData(inst)
+
+			var err error
+			switch bc := blueprint.(type) {
+			case BlueprintR1C:
+				bc.DecompressR1C(&r1c, calldata)
+				_, custom := blueprint.(BlueprintSolvable)
+				var toSolve []Term
+				for _, l := range []LinearExpression{r1c.L, r1c.R, r1c.O} {
+					for _, t := range l {
+						if !isSolved(t.WireID()) {
+							toSolve = append(toSolve, t)
+						}
+					}
+				}
+				if !custom && len(toSolve) > 1 {
+					err = fmt.Errorf("constraint %d has more than one wire to solve", inst.ConstraintOffset)
+				} else if !custom && len(toSolve) == 1 && toSolve[0].CID == CoeffIdZero {
+					err = fmt.Errorf("constraint %d solves a wire with a zero coefficient", inst.ConstraintOffset)
+				}
+				// Solve instantiates the wires of the constraint of a BlueprintSolvable.
+				for i := 0; i < len(toSolve) && err == nil; i++ {
+					if !solved[toSolve[i].WireID()] {
+						err = solve(toSolve[i].WireID())
+					}
+				}
+			case BlueprintHint:
+				bc.DecompressHint(&hm, calldata)
+				for _, l := range hm.Inputs {
+					for _, t := range l {
+						if !t.IsConstant() && !isSolved(t.WireID()) && err == nil {
+							err = fmt.Errorf("hint reads unsolved wire %d", t.WireID())
+						}
+					}
+				}
+				for wID := hm.OutputRange.Start; wID < hm.OutputRange.End && err == nil; wID++ {
+					err = solve(int(wID))
+				}
+			case BlueprintWires:
+				in := bc.InputWires(calldata)
+				for wID := in(); wID != -1 && err == nil; wID = in() {
+					if !isSolved(wID) {
+						err = fmt.Errorf("instruction reads unsolved wire %d", wID)
+					}
+				}
+				out := bc.OutputWires(calldata)
+				for wID := out(); wID != -1 && err == nil; wID = out() {
+					err = solve(wID)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("%w: instruction %d: %s", ErrInvalidEncoding, iID, err)
+			}
+		}
+
+		for _, wID := range pending {
+			inLevel[wID] = false
+		}
+		pending = pending[:0]
+	}
+
+	if nbSolved != nbWires {
+		return fmt.Errorf("%w: %d wires out of %d are not solved", ErrInvalidEncoding, nbWires-nbSolved, nbWires)
+	}
+	return nil
+}
+
+// calldataChecker is implemented by the blueprints whose calldata has an inner layout,
+// checked before the calldata of a decoded system is decompressed.
+type calldataChecker interface {
+	checkCallData(calldata []uint32) error
+}
+
+// capAlloc bounds the capacity of a slice allocated from an untrusted count.
+func capAlloc(n uint64) int {
+	if n > maxDecodeAlloc {
+		return maxDecodeAlloc
+	}
+	return int(n)
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (enc *encoder) write(b []byte) {
+	if enc.err != nil {
+		return
+	}
+	_, enc.err = enc.w.Write(b)
+}
+
+func (enc *encoder) writeUint16(v uint16) {
+	binary.BigEndian.PutUint16(enc.buf[:2], v)
+	enc.write(enc.buf[:2])
+}
+
+func (enc *encoder) writeUint32(v uint32) {
+	binary.BigEndian.PutUint32(enc.buf[:4], v)
+	enc.write(enc.buf[:4])
+}
+
+func (enc *encoder) writeUint64(v uint64) {
+	binary.BigEndian.PutUint64(enc.buf[:8], v)
+	enc.write(enc.buf[:8])
+}
+
+func (enc *encoder) writeBytes(b []byte) {
+	enc.writeUint32(uint32(len(b)))
+	enc.write(b)
+}
+
+func (enc *encoder) writeString(s string) {
+	enc.writeUint32(uint32(len(s)))
+	if enc.err == nil {
+		_, enc.err = enc.w.WriteString(s)
+	}
+}
+
+func (enc *encoder) writeStrings(s []string) {
+	enc.writeUint32(uint32(len(s)))
+	for _, v := range s {
+		enc.writeString(v)
+	}
+}
+
+func (enc *encoder) writeInts(s []int) {
+	enc.writeUint32(uint32(len(s)))
+	for _, v := range s {
+		if v < 0 || v > math.MaxUint32 {
+			if enc.err == nil {
+				enc.err = fmt.Errorf("can't encode %d as uint32", v)
+			}
+			return
+		}
+		enc.writeUint32(uint32(v))
+	}
+}
+
+func (enc *encoder) writeHeader(cs *system) {
+	enc.write([]byte(binaryMagic))
+	enc.writeUint16(BinaryVersion)
+	enc.writeUint16(uint16(cs.Type))
+	enc.writeBytes(cs.Field().Bytes())
+	enc.writeUint64(uint64(len(cs.Instructions)))
+	enc.writeUint64(uint64(len(cs.CallData)))
+	enc.writeUint64(uint64(len(cs.Coefficients)))
+	enc.writeUint64(uint64(cs.NbConstraints))
+	enc.writeUint64(uint64(cs.NbInternalVariables))
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+	err error
+}
+
+func (dec *decoder) read(b []byte) {
+	if dec.err != nil {
+		return
+	}
+	_, dec.err = io.ReadFull(dec.r, b)
+}
+
+func (dec *decoder) readUint16() uint16 {
+	dec.read(dec.buf[:2])
+	return binary.BigEndian.Uint16(dec.buf[:2])
+}
+
+func (dec *decoder) readUint32() uint32 {
+	dec.read(dec.buf[:4])
+	return binary.BigEndian.Uint32(dec.buf[:4])
+}
+
+func (dec *decoder) readUint64() uint64 {
+	dec.read(dec.buf[:8])
+	return binary.BigEndian.Uint64(dec.buf[:8])
+}
+
+// readCount reads a uint64 count and ensures it fits in an int on all platforms.
+func (dec *decoder) readCount() uint64 {
+	n := dec.readUint64()
+	if dec.err == nil && n > math.MaxInt32 {
+		dec.err = fmt.Errorf("%w: count %d too large", ErrInvalidEncoding, n)
+	}
+	return n
+}
+
+func (dec *decoder) readBytes() []byte {
+	n := dec.readUint32()
+	if dec.err != nil {
+		return nil
+	}
+	// grow progressively to avoid large allocations from an untrusted length.
+	var b bytes.Buffer
+	if _, err := io.CopyN(&b, dec.r, int64(n)); err != nil {
+		dec.err = err
+		return nil
+	}
+	return b.Bytes()
+}
+
+func (dec *decoder) readString() string {
+	return string(dec.readBytes())
+}
+
+func (dec *decoder) readStrings() []string {
+	n := dec.readUint32()
+	r := make([]string, 0, capAlloc(uint64(n)))
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		r = append(r, dec.readString())
+	}
+	return r
+}
+
+func (dec *decoder) readInts() []int {
+	n := dec.readUint32()
+	r := make([]int, 0, capAlloc(uint64(n)))
+	for i := uint32(0); i < n && dec.err == nil; i++ {
+		r = append(r, int(dec.readUint32()))
+	}
+	return r
+}
+
+// readUint32s reads n uint32 in chunks, the hot path of the decoder.
+func (dec *decoder) readUint32s(n uint64) []uint32 {
+	r := make([]uint32, 0, capAlloc(n))
+	var chunk [4096]byte
+	for n > 0 && dec.err == nil {
+		m := uint64(len(chunk) / 4)
+		if n < m {
+			m = n
+		}
+		dec.read(chunk[:4*m])
+		if dec.err != nil {
+			break
+		}
+		for i := uint64(0); i < m; i++ {
+			r = append(r, binary.BigEndian.Uint32(chunk[4*i:]))
+		}
+		n -= m
+	}
+	return r
+}
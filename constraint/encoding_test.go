@@ -0,0 +1,143 @@
+package cs
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+func init() {
+	csolver.RegisterHint(csolver.NewHint("encoding_test_bits", func(_ *big.Int, inputs, outputs []*big.Int) error {
+		for i := range outputs {
+			outputs[i].SetUint64(uint64(inputs[0].Bit(i)))
+		}
+		return nil
+	}))
+}
+
+// encodingTestSystem returns a small system using hints, the generic and the fixed-shape
+// blueprints, and a commitment.
+func encodingTestSystem(tb testing.TB) *R1CS {
+	r := NewR1CS(16)
+	r.AddPublicVariable("1")
+	y := uint32(r.AddPublicVariable("Y"))
+	x := uint32(r.AddSecretVariable("X"))
+	g := r.AddBlueprint(&BlueprintGenericR1C{})
+	one := Term{CID: CoeffIdOne, VID: 0}
+
+	// y = b0 + 2*b1, with boolean bits
+	b, err := r.AddHint("encoding_test_bits", []LinearExpression{{{CID: CoeffIdOne, VID: y}}}, 2)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, bit := range b {
+		r.AddR1C(R1C{
+			L: LinearExpression{{CID: CoeffIdOne, VID: uint32(bit)}},
+			R: LinearExpression{one, {CID: CoeffIdMinusOne, VID: uint32(bit)}},
+			O: LinearExpression{{CID: CoeffIdZero, VID: 0}},
+		}, g)
+	}
+	r.AddR1C(R1C{
+		L: LinearExpression{one},
+		R: LinearExpression{{CID: CoeffIdOne, VID: uint32(b[0])}, {CID: CoeffIdTwo, VID: uint32(b[1])}},
+		O: LinearExpression{{CID: CoeffIdOne, VID: y}},
+	}, g)
+
+	// v = 7 * x * y
+	c7 := r.AddCoeff(Element{7})
+	v := uint32(r.AddInternalVariable())
+	r.AddR1C(R1C{
+		L: LinearExpression{{CID: c7, VID: x}},
+		R: LinearExpression{{CID: CoeffIdOne, VID: y}},
+		O: LinearExpression{{CID: CoeffIdOne, VID: v}},
+	}, g)
+
+	if err := r.AddCommitment(NewCommitment([]int{int(x), int(v)}, 0)); err != nil {
+		tb.Fatal(err)
+	}
+	r.CompactBlueprints()
+	return r
+}
+
+func FuzzReadFrom(f *testing.F) {
+	r := encodingTestSystem(f)
+	var bin, gob bytes.Buffer
+	if _, err := r.WriteTo(&bin); err != nil {
+		f.Fatal(err)
+	}
+	if _, err := r.WriteGobTo(&gob); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(bin.Bytes())
+	f.Add(gob.Bytes())
+
+	var empty bytes.Buffer
+	if _, err := NewR1CS(0).WriteTo(&empty); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(empty.Bytes())
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var r R1CS
+		if _, err := r.ReadFrom(bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		// a decoded system can be walked and solved, errors are expected.
+		r.AnalyzeWires()
+		r.DependencyGraph()
+
+		nbPublic, nbSecret := r.GetNbPublicVariables()-1, r.GetNbSecretVariables()
+		if nbPublic < 0 || nbPublic+nbSecret > 1<<10 {
+			return
+		}
+		w, err := witness.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := make(chan any, nbPublic+nbSecret)
+		for i := 0; i < nbPublic+nbSecret; i++ {
+			values <- uint64(i + 1)
+		}
+		close(values)
+		if err := w.Fill(nbPublic, nbSecret, values); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = r.Solve(w, csolver.OverrideHint(csolver.GetHintID(CommitmentHintName), csolver.InvZeroHint))
+	})
+}
+
+func TestReadFromRoundTrip(t *testing.T) {
+	r := encodingTestSystem(t)
+	var bin bytes.Buffer
+	if _, err := r.WriteTo(&bin); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded R1CS
+	if _, err := decoded.ReadFrom(bytes.NewReader(bin.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if _, err := decoded.WriteTo(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bin.Bytes(), again.Bytes()) {
+		t.Fatal("encoding is not stable through a round trip")
+	}
+
+	w, _ := witness.New()
+	values := make(chan any, 2)
+	values <- uint64(3)
+	values <- uint64(2)
+	close(values)
+	if err := w.Fill(1, 1, values); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.Solve(w, csolver.OverrideHint(csolver.GetHintID(CommitmentHintName), csolver.InvZeroHint)); err != nil {
+		t.Fatal(err)
+	}
+}
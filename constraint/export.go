@@ -0,0 +1,264 @@
+package cs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// CoeffFormat is the textual representation of the coefficients in exported constraint
+// systems.
+type CoeffFormat int
+
+const (
+	CoeffDecimal CoeffFormat = iota
+	CoeffHex                 // 0x prefixed
+)
+
+func (f CoeffFormat) format(e *fr.Element) string {
+	if f == CoeffHex {
+		return "0x" + e.Text(16)
+	}
+	return e.Text(10)
+}
+
+// ForEachR1C calls f with the instruction id, the constraint id (Instruction.ConstraintOffset)
+// and the decompressed value of each R1C of the system, in instruction order, skipping hints
+// and the instructions of other blueprints. The R1C is reused between calls. It stops at the
+// first error returned by f.
+func (cs *system) ForEachR1C(f func(iID, cID int, r1c *R1C) error) error {
+	var r1c R1C
+	for iID, inst := range cs.Instructions {
+		bc, ok := cs.Blueprints[inst.BlueprintID].(BlueprintR1C)
+		if !ok {
+			continue
+		}
+		bc.DecompressR1C(&r1c, cs.GetCallData(inst))
+		if err := f(iID, int(inst.ConstraintOffset), &r1c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportText writes the constraints of the system, one "L ⋅ R == O" per line, with wire
+// names and coefficient values (see R1C.String).
+//
+// If the system has a symbol table, internal wires are named after it and each constraint
+// is followed by the innermost source location it originates from, as a "// " comment.
+func (cs *system) ExportText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	r := symbolResolver{cs}
+	err := cs.ForEachR1C(func(iID, _ int, r1c *R1C) error {
+		bw.WriteString(r1c.String(r))
+		if entry, ok := cs.debugEntry(iID); ok {
+			if source := cs.SymbolTable.source(entry.Stack); source != "" {
+				bw.WriteString(" // ")
+				bw.WriteString(source)
+			}
+		}
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// jsonTerm is the JSON representation of a Term in ExportJSON.
+type jsonTerm struct {
+	Wire  int    `json:"wire"`
+	Coeff string `json:"coeff"`
+}
+
+// ExportJSON writes the system as a JSON object:
+//
+//	{
+//	  "field": "0x...", "nbPublic": n, "nbSecret": n, "nbInternal": n,
+//	  "public": [names], "secret": [names],
+//	  "constraints": [{"L": [{"wire": id, "coeff": "c"}, ...], "R": [...], "O": [...]}, ...]
+//	}
+//
+// Wire ids follow the solution vector layout: public wires (starting with the constant
+// one wire), secret wires, then internal wires. Constraints are streamed, one per line,
+// such that large systems don't have to fit twice in memory.
+func (cs *system) ExportJSON(w io.Writer, format CoeffFormat) error {
+	bw := bufio.NewWriter(w)
+
+	header := struct {
+		Field      string   `json:"field"`
+		NbPublic   int      `json:"nbPublic"`
+		NbSecret   int      `json:"nbSecret"`
+		NbInternal int      `json:"nbInternal"`
+		Public     []string `json:"public"`
+		Secret     []string `json:"secret"`
+	}{
+		Field:      "0x" + cs.Field().Text(16),
+		NbPublic:   cs.GetNbPublicVariables(),
+		NbSecret:   cs.GetNbSecretVariables(),
+		NbInternal: cs.GetNbInternalVariables(),
+		Public:     cs.Public,
+		Secret:     cs.Secret,
+	}
+	b, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// re-open the header object to append the constraints.
+	bw.Write(b[:len(b)-1])
+	bw.WriteString(`,"constraints":[`)
+	bw.WriteByte('\n')
+
+	toJSON := func(l LinearExpression) []jsonTerm {
+		r := make([]jsonTerm, len(l))
+		for i, t := range l {
+			r[i] = jsonTerm{Wire: t.WireID(), Coeff: format.format(&cs.Coefficients[t.CoeffID()])}
+		}
+		return r
+	}
+	first := true
+	err = cs.ForEachR1C(func(_, _ int, r1c *R1C) error {
+		if !first {
+			bw.WriteString(",\n")
+		}
+		first = false
+		b, err := json.Marshal(struct {
+			L []jsonTerm `json:"L"`
+			R []jsonTerm `json:"R"`
+			O []jsonTerm `json:"O"`
+		}{toJSON(r1c.L), toJSON(r1c.R), toJSON(r1c.O)})
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("\n]}\n")
+	return bw.Flush()
+}
+
+// SparseMatrix is a sparse matrix in coordinate (COO) format. Rows are constraints and
+// columns are wires, following the solution vector layout. Entries with a zero coefficient
+// are omitted; a wire appearing twice in a linear expression results in duplicate entries,
+// to be summed.
+type SparseMatrix struct {
+	NbRows, NbCols int
+	Rows, Cols     []int
+	Values         []fr.Element
+}
+
+// CSRMatrix is a sparse matrix in compressed sparse row (CSR) format: the entries of row i
+// are at indexes [RowPtr[i], RowPtr[i+1]) of ColIdx and Values, sorted by column.
+type CSRMatrix struct {
+	NbRows, NbCols int
+	RowPtr         []int
+	ColIdx         []int
+	Values         []fr.Element
+}
+
+// Matrices returns the A, B and C matrices of the R1CS, such that A⋅w ∘ B⋅w == C⋅w for a
+// solution w.
+func (cs *system) Matrices() (a, b, c SparseMatrix) {
+	nbWires := cs.GetNbPublicVariables() + cs.GetNbSecretVariables() + cs.GetNbInternalVariables()
+	for _, m := range []*SparseMatrix{&a, &b, &c} {
+		m.NbRows, m.NbCols = cs.GetNbConstraints(), nbWires
+	}
+	appendRow := func(m *SparseMatrix, row int, l LinearExpression) {
+		for _, t := range l {
+			if t.CoeffID() == CoeffIdZero {
+				continue
+			}
+			m.Rows = append(m.Rows, row)
+			m.Cols = append(m.Cols, t.WireID())
+			m.Values = append(m.Values, cs.Coefficients[t.CoeffID()])
+		}
+	}
+	_ = cs.ForEachR1C(func(_, cID int, r1c *R1C) error {
+		appendRow(&a, cID, r1c.L)
+		appendRow(&b, cID, r1c.R)
+		appendRow(&c, cID, r1c.O)
+		return nil
+	})
+	return
+}
+
+// CSR converts the matrix to the CSR format.
+func (m *SparseMatrix) CSR() CSRMatrix {
+	perm := make([]int, len(m.Rows))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		if m.Rows[perm[i]] != m.Rows[perm[j]] {
+			return m.Rows[perm[i]] < m.Rows[perm[j]]
+		}
+		return m.Cols[perm[i]] < m.Cols[perm[j]]
+	})
+
+	r := CSRMatrix{
+		NbRows: m.NbRows,
+		NbCols: m.NbCols,
+		RowPtr: make([]int, m.NbRows+1),
+		ColIdx: make([]int, len(perm)),
+		Values: make([]fr.Element, len(perm)),
+	}
+	for i, k := range perm {
+		r.RowPtr[m.Rows[k]+1]++
+		r.ColIdx[i] = m.Cols[k]
+		r.Values[i] = m.Values[k]
+	}
+	for i := 0; i < m.NbRows; i++ {
+		r.RowPtr[i+1] += r.RowPtr[i]
+	}
+	return r
+}
+
+// WriteCOO writes the matrix as text: a header line "rows cols nnz", then one
+// "row col value" line per entry.
+func (m *SparseMatrix) WriteCOO(w io.Writer, format CoeffFormat) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d %d\n", m.NbRows, m.NbCols, len(m.Values))
+	for i := range m.Values {
+		bw.WriteString(strconv.Itoa(m.Rows[i]))
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.Itoa(m.Cols[i]))
+		bw.WriteByte(' ')
+		bw.WriteString(format.format(&m.Values[i]))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WriteCSR writes the matrix as text: a header line "rows cols nnz", then the row
+// pointers, the column indexes and the values, each on a single space separated line.
+func (m *CSRMatrix) WriteCSR(w io.Writer, format CoeffFormat) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d %d\n", m.NbRows, m.NbCols, len(m.Values))
+	writeInts := func(s []int) {
+		for i, v := range s {
+			if i != 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteString(strconv.Itoa(v))
+		}
+		bw.WriteByte('\n')
+	}
+	writeInts(m.RowPtr)
+	writeInts(m.ColIdx)
+	for i := range m.Values {
+		if i != 0 {
+			bw.WriteByte(' ')
+		}
+		bw.WriteString(format.format(&m.Values[i]))
+	}
+	bw.WriteByte('\n')
+	return bw.Flush()
+}
@@ -0,0 +1,125 @@
+package cs
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// fingerprintDomain separates the fingerprint hash from other uses of sha256;
+// it must change if the hashed content changes.
+const fingerprintDomain = "gnark-tiny-prover-g16/r1cs/fingerprint/v2"
+
+// Fingerprint is a canonical identifier of a constraint system.
+type Fingerprint [sha256.Size]byte
+
+// String returns the hex encoding of the fingerprint.
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// ParseFingerprint decodes the hex encoding of a fingerprint.
+func ParseFingerprint(s string) (Fingerprint, error) {
+	var f Fingerprint
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return f, fmt.Errorf("invalid fingerprint: %w", err)
+	}
+	if len(b) != len(f) {
+		return f, fmt.Errorf("invalid fingerprint: expected %d bytes, got %d", len(f), len(b))
+	}
+	copy(f[:], b)
+	return f, nil
+}
+
+// Fingerprint returns the sha256 hash of the scalar field, input names, blueprints, hint
+// dependencies, instructions (with their calldata), coefficients and commitment info of
+// the system, in a canonical order which doesn't depend on the serialization format.
+//
+// Blueprints are identified by their tag (BlueprintTagged) and binary payload, if any, such
+// that moving or renaming a blueprint type doesn't change the fingerprint. Custom
+// blueprints without a tag are identified by their registered name (BlueprintName). It
+// panics if a blueprint fails to marshal its payload.
+//
+// Levels are not hashed, they are derived from the instructions. Passes rewriting the
+// instructions, such as CompactBlueprints, change the fingerprint.
+func (cs *system) Fingerprint() Fingerprint {
+	h := sha256.New()
+	enc := &encoder{w: bufio.NewWriter(h)}
+
+	enc.writeString(fingerprintDomain)
+	enc.writeBytes(cs.Field().Bytes())
+	enc.writeUint16(uint16(cs.Type))
+	enc.writeUint64(uint64(cs.NbConstraints))
+	enc.writeUint64(uint64(cs.NbInternalVariables))
+	enc.writeStrings(cs.Public)
+	enc.writeStrings(cs.Secret)
+
+	enc.writeUint32(uint32(len(cs.Blueprints)))
+	for _, b := range cs.Blueprints {
+		if t, ok := b.(BlueprintTagged); ok {
+			enc.write([]byte{1})
+			enc.writeString(t.BlueprintTag())
+		} else {
+			enc.write([]byte{0})
+			enc.writeString(BlueprintName(b))
+		}
+		var payload []byte
+		if m, ok := b.(encoding.BinaryMarshaler); ok && enc.err == nil {
+			payload, enc.err = m.MarshalBinary()
+		}
+		enc.writeBytes(payload)
+	}
+
+	hintIDs := make([]hintsolver.HintID, 0, len(cs.MHintsDependencies))
+	for id := range cs.MHintsDependencies {
+		hintIDs = append(hintIDs, id)
+	}
+	sort.Slice(hintIDs, func(i, j int) bool { return hintIDs[i] < hintIDs[j] })
+	enc.writeUint32(uint32(len(hintIDs)))
+	for _, id := range hintIDs {
+		enc.writeUint32(uint32(id))
+		enc.writeString(cs.MHintsDependencies[id])
+	}
+
+	// StartCallData depends on the calldata layout; we hash the calldata of each instruction instead.
+	enc.writeUint64(uint64(len(cs.Instructions)))
+	for _, inst := range cs.Instructions {
+		calldata := cs.GetCallData(inst)
+		enc.writeUint32(uint32(inst.BlueprintID))
+		enc.writeUint32(inst.ConstraintOffset)
+		enc.writeUint32(uint32(len(calldata)))
+		for _, v := range calldata {
+			enc.writeUint32(v)
+		}
+	}
+
+	enc.writeUint64(uint64(len(cs.Coefficients)))
+	for i := range cs.Coefficients {
+		b := cs.Coefficients[i].Bytes()
+		enc.write(b[:])
+	}
+
+	enc.writeInts(cs.CommitmentInfo.Committed)
+	enc.writeUint32(uint32(cs.CommitmentInfo.NbPrivateCommitted))
+	enc.writeUint32(uint32(cs.CommitmentInfo.HintID))
+	enc.writeUint32(uint32(cs.CommitmentInfo.CommitmentIndex))
+	enc.writeInts(cs.CommitmentInfo.CommittedAndCommitment)
+
+	// writing to a hash never fails, only a blueprint payload can
+	if enc.err == nil {
+		enc.err = enc.w.Flush()
+	}
+	if enc.err != nil {
+		panic(enc.err)
+	}
+
+	var f Fingerprint
+	h.Sum(f[:0])
+	return f
+}
@@ -0,0 +1,267 @@
+package cs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/blang/semver/v4"
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/logger"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// Upstream gnark serializes its bn254 constraint systems with CBOR (see gnark
+// constraint/bn254/system.go). The layout of the system is the one of this package;
+// blueprints and, from gnark 0.9, the commitments are encoded as tagged values, with tags
+// allocated in registration order.
+const (
+	gnarkTagGenericHint        = 5309735
+	gnarkTagGenericR1C         = 5309736
+	gnarkTagGroth16Commitments = 5309742 // gnark 0.9 and later
+
+	gnarkSystemR1CS = 1
+)
+
+// gnarkTags names the tags registered by gnark 0.9 and later, which blueprints this package
+// doesn't implement (sparse R1C are PLONK constraints).
+var gnarkTags = map[uint64]string{
+	5309737: "BlueprintGenericSparseR1C",
+	5309738: "BlueprintSparseR1CAdd",
+	5309739: "BlueprintSparseR1CMul",
+	5309740: "BlueprintSparseR1CBool",
+	5309741: "BlueprintLookupHint",
+	5309743: "PlonkCommitments",
+}
+
+// Upstream versions, as written in the serialization header, with a known layout:
+//
+//   - from 0.8.1-alpha (the development versions following 0.8.0, including the one this
+//     module depends on) the commitment info is a single Commitment, as in this package;
+//   - from 0.9.0 (up to 0.10.x, which development versions report 0.10.0-alpha) it is a
+//     list of Groth16Commitments, solved with other hint inputs and hashed differently by
+//     the prover: only systems without commitment can be mapped.
+//
+// Released gnark 0.8.0 predates the blueprint based layout.
+var (
+	minGnarkVersion         = semver.MustParse("0.8.1-0")
+	gnarkCommitmentsVersion = semver.MustParse("0.9.0-0")
+	maxGnarkVersion         = semver.MustParse("0.11.0-0") // first version with an unknown layout
+)
+
+// ErrUnsupportedGnarkVersion is returned when reading a constraint system serialized by a
+// gnark version which layout can't be mapped into a R1CS.
+var ErrUnsupportedGnarkVersion = errors.New("unsupported gnark constraint system")
+
+// gnarkSystem mirrors the serialized fields of an upstream gnark bn254 R1CS.
+// Logs are ignored.
+type gnarkSystem struct {
+	GnarkVersion string
+	ScalarField  string
+	Type         uint16
+
+	Instructions []struct {
+		BlueprintID      uint32
+		ConstraintOffset uint32
+		StartCallData    uint64
+	}
+	Blueprints []cbor.RawTag
+	CallData   []uint32
+
+	NbConstraints       int
+	NbInternalVariables int
+	Public, Secret      []string
+
+	MHintsDependencies map[uint32]string
+	Levels             [][]int
+	CommitmentInfo     cbor.RawMessage // Commitment, or Groth16Commitments from gnark 0.9
+
+	// from gnark 0.9, only read to reject systems using GKR
+	GkrInfo struct {
+		NbInstances int
+	}
+
+	Coefficients []fr.Element
+
+	DebugInfo []struct {
+		Format    string
+		ToResolve []LinearExpression
+		Stack     []int
+	}
+	SymbolTable struct {
+		Locations []Location
+		Functions []Function
+	}
+	MDebug map[int]int // constraint id to DebugInfo id
+}
+
+// isGnarkEncoding returns true if the first byte of a serialized constraint system is the
+// header of a CBOR map, as written by gnark. It can't be the first byte of a gob stream
+// (a message length) nor of the binary format.
+func isGnarkEncoding(b byte) bool {
+	return b >= 0xa0 && b <= 0xbf
+}
+
+// ReadGnarkFrom decodes a bn254 R1CS serialized by upstream gnark (ccs.WriteTo, CBOR based)
+// and maps it into cs. Instructions, calldata, coefficients, levels, hint dependencies and
+// commitment info are kept as is; gnark logs are dropped. Debug info, if any, is kept in
+// the symbol table of cs, keyed by instruction instead of constraint.
+//
+// The gnark version is read from the serialization header and kept in cs.GnarkVersion.
+// Versions from 0.8.1-alpha to 0.10.x are mapped, others are rejected with
+// ErrUnsupportedGnarkVersion; from 0.9.0, systems with commitments or GKR are rejected too.
+// A warning is logged if the version differs from the gnark version in go.mod.
+func (cs *system) ReadGnarkFrom(r io.Reader) (int64, error) {
+	dm, err := cbor.DecOptions{
+		MaxArrayElements: 134217728,
+		MaxMapPairs:      134217728,
+	}.DecMode()
+	if err != nil {
+		return 0, err
+	}
+	decoder := dm.NewDecoder(r)
+
+	var upstream gnarkSystem
+	if err := decoder.Decode(&upstream); err != nil {
+		return int64(decoder.NumBytesRead()), fmt.Errorf("decoding gnark R1CS: %w", err)
+	}
+	return int64(decoder.NumBytesRead()), cs.fromGnark(&upstream)
+}
+
+func (cs *system) fromGnark(upstream *gnarkSystem) error {
+	version, err := semver.Parse(upstream.GnarkVersion)
+	if err != nil {
+		return fmt.Errorf("when parsing gnark version: %w", err)
+	}
+	if version.LT(minGnarkVersion) || version.GTE(maxGnarkVersion) {
+		return fmt.Errorf("%w: gnark version %s, supported versions are 0.8.1-alpha to 0.10.x", ErrUnsupportedGnarkVersion, version)
+	}
+	if !version.EQ(gnark.Version) {
+		log := logger.Logger()
+		log.Warn().Str("pinned", gnark.Version.String()).Str("object", version.String()).Msg("gnark version mismatch with constraint system, there are no guarantees on compatibility")
+	}
+	if upstream.Type != gnarkSystemR1CS {
+		return fmt.Errorf("%w: gnark %s: system type %d is not a R1CS", ErrUnsupportedGnarkVersion, version, upstream.Type)
+	}
+
+	*cs = system{}
+	cs.GnarkVersion = version.String()
+	cs.ScalarField = upstream.ScalarField
+	cs.Type = ConstrainSystemTypeR1CS
+	cs.NbConstraints = upstream.NbConstraints
+	cs.NbInternalVariables = upstream.NbInternalVariables
+	cs.Public = upstream.Public
+	cs.Secret = upstream.Secret
+	cs.CallData = upstream.CallData
+	cs.Levels = upstream.Levels
+	cs.Coefficients = upstream.Coefficients
+
+	cs.Blueprints = make([]Blueprint, len(upstream.Blueprints))
+	for i, b := range upstream.Blueprints {
+		switch b.Number {
+		case gnarkTagGenericHint:
+			cs.Blueprints[i] = &BlueprintGenericHint{}
+		case gnarkTagGenericR1C:
+			cs.Blueprints[i] = &BlueprintGenericR1C{}
+		default:
+			if name, ok := gnarkTags[b.Number]; ok && version.GTE(gnarkCommitmentsVersion) {
+				return fmt.Errorf("%w: gnark %s: blueprint %s is not supported", ErrUnsupportedGnarkVersion, version, name)
+			}
+			return fmt.Errorf("%w: gnark %s: blueprint tag %d", ErrUnsupportedGnarkVersion, version, b.Number)
+		}
+	}
+
+	// the instructions of gnark 0.9 have a wire offset, only used by blueprints which are
+	// not supported.
+	cs.Instructions = make([]Instruction, len(upstream.Instructions))
+	for i, inst := range upstream.Instructions {
+		cs.Instructions[i] = Instruction{
+			BlueprintID:      BlueprintID(inst.BlueprintID),
+			ConstraintOffset: inst.ConstraintOffset,
+			StartCallData:    inst.StartCallData,
+		}
+	}
+
+	cs.MHintsDependencies = make(map[hintsolver.HintID]string, len(upstream.MHintsDependencies))
+	for id, name := range upstream.MHintsDependencies {
+		cs.MHintsDependencies[hintsolver.HintID(id)] = name
+	}
+
+	if version.LT(gnarkCommitmentsVersion) {
+		if len(upstream.CommitmentInfo) != 0 {
+			if err := cbor.Unmarshal(upstream.CommitmentInfo, &cs.CommitmentInfo); err != nil {
+				return fmt.Errorf("%w: gnark %s: commitment info: %s", ErrUnsupportedGnarkVersion, version, err)
+			}
+		}
+	} else {
+		if err := checkGnarkCommitments(upstream.CommitmentInfo); err != nil {
+			return fmt.Errorf("%w: gnark %s: %s", ErrUnsupportedGnarkVersion, version, err)
+		}
+		if upstream.GkrInfo.NbInstances != 0 {
+			return fmt.Errorf("%w: gnark %s: GKR is not supported", ErrUnsupportedGnarkVersion, version)
+		}
+	}
+
+	if err := cs.CheckSerializationHeader(); err != nil {
+		return err
+	}
+	if err := cs.checkInstructions(); err != nil {
+		return err
+	}
+	return cs.gnarkSymbolTable(upstream)
+}
+
+// checkGnarkCommitments checks the commitment info of gnark 0.9 and later is an empty list
+// of Groth16 commitments.
+func checkGnarkCommitments(raw cbor.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	var tag cbor.RawTag
+	if err := cbor.Unmarshal(raw, &tag); err != nil {
+		return fmt.Errorf("commitment info: %w", err)
+	}
+	if tag.Number != gnarkTagGroth16Commitments {
+		if name, ok := gnarkTags[tag.Number]; ok {
+			return fmt.Errorf("commitment info: %s are not supported", name)
+		}
+		return fmt.Errorf("commitment info: tag %d", tag.Number)
+	}
+	var commitments []cbor.RawMessage
+	if err := cbor.Unmarshal(tag.Content, &commitments); err != nil {
+		return fmt.Errorf("commitment info: %w", err)
+	}
+	if len(commitments) != 0 {
+		return fmt.Errorf("%d commitment(s): only the commitments of gnark 0.8 are supported", len(commitments))
+	}
+	return nil
+}
+
+// gnarkSymbolTable builds the symbol table of cs from the upstream debug info.
+func (cs *system) gnarkSymbolTable(upstream *gnarkSystem) error {
+	if len(upstream.MDebug) == 0 {
+		return nil
+	}
+	t := NewSymbolTable()
+	t.Functions = upstream.SymbolTable.Functions
+	t.Locations = upstream.SymbolTable.Locations
+	t.DebugInfo = make([]DebugEntry, len(upstream.DebugInfo))
+	for i, entry := range upstream.DebugInfo {
+		t.DebugInfo[i] = DebugEntry{Format: entry.Format, ToResolve: entry.ToResolve, Stack: entry.Stack}
+	}
+	for iID, inst := range cs.Instructions {
+		if _, ok := cs.Blueprints[inst.BlueprintID].(BlueprintR1C); !ok {
+			continue
+		}
+		if id, ok := upstream.MDebug[int(inst.ConstraintOffset)]; ok {
+			t.MDebug[iID] = id
+		}
+	}
+	if err := cs.checkSymbolTable(t); err != nil {
+		return err
+	}
+	cs.SymbolTable = t
+	return nil
+}
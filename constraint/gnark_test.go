@@ -0,0 +1,103 @@
+package cs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints"
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+// gnarkTestCircuit decomposes X in bits (hint dependency) and recomposes it into Y.
+type gnarkTestCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *gnarkTestCircuit) Define(api frontend.API) error {
+	b := bits.ToBinary(api, c.X, bits.WithNbDigits(8))
+	api.AssertIsEqual(bits.FromBinary(api, b), c.Y)
+	return nil
+}
+
+// gnarkTestSystem compiles gnarkTestCircuit with the gnark frontend and returns its gnark
+// serialization.
+func gnarkTestSystem(t *testing.T) []byte {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &gnarkTestCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := ccs.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gnarkTestWitness(t *testing.T, x, y uint64) witness.Witness {
+	w, err := witness.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(chan any, 2)
+	values <- y
+	values <- x
+	close(values)
+	if err := w.Fill(1, 1, values); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestReadGnarkFrom(t *testing.T) {
+	var r R1CS
+	if _, err := r.ReadGnarkFrom(bytes.NewReader(gnarkTestSystem(t))); err != nil {
+		t.Fatal(err)
+	}
+	if r.GnarkVersion == "" {
+		t.Fatal("gnark version not set")
+	}
+	if len(r.MHintsDependencies) == 0 {
+		t.Fatal("expected hint dependencies")
+	}
+
+	resolved, report := hints.ResolveHintDependencies(r.MHintsDependencies, r.GnarkVersion)
+	if len(report.Unresolved) != 0 {
+		t.Fatalf("unresolved hints: %v", report.Unresolved)
+	}
+	if _, err := r.Solve(gnarkTestWitness(t, 0xa5, 0xa5), csolver.WithHints(resolved...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Solve(gnarkTestWitness(t, 0xa5, 0xa4), csolver.WithHints(resolved...)); err == nil {
+		t.Fatal("expected an unsatisfied constraint")
+	}
+}
+
+func TestReadGnarkFromUnsupportedVersion(t *testing.T) {
+	encoded := gnarkTestSystem(t)
+	var r R1CS
+	if _, err := r.ReadGnarkFrom(bytes.NewReader(encoded)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the version is a CBOR text string, replaced by versions of the same length.
+	for _, version := range []string{"0.7.0-alpha", "0.11.0-alph", "1.0.0-alpha"} {
+		if len(version) != len(r.GnarkVersion) {
+			t.Fatalf("test version %s doesn't have the length of %s", version, r.GnarkVersion)
+		}
+		patched := bytes.Replace(encoded, []byte(r.GnarkVersion), []byte(version), 1)
+		if bytes.Equal(patched, encoded) {
+			t.Fatal("gnark version not found in the encoding")
+		}
+		var rejected R1CS
+		if _, err := rejected.ReadGnarkFrom(bytes.NewReader(patched)); !errors.Is(err, ErrUnsupportedGnarkVersion) {
+			t.Fatalf("gnark %s: expected ErrUnsupportedGnarkVersion, got %v", version, err)
+		}
+	}
+}
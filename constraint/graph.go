@@ -0,0 +1,245 @@
+package cs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DependencyGraph is the instruction dependency graph of a constraint system: an edge goes
+// from the instruction solving a wire to each instruction reading it. Inputs have no
+// producer and are not part of the graph.
+type DependencyGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+
+	NbLevels int `json:"nbLevels"`
+
+	// CriticalPath is the longest chain of dependent instructions; its length bounds the
+	// number of sequential steps of the solver.
+	CriticalPath []int `json:"criticalPath"`
+	// CriticalPathHints lists the names of the hints on the critical path, in order.
+	CriticalPathHints []string `json:"criticalPathHints"`
+}
+
+// GraphNode is an instruction of the DependencyGraph.
+type GraphNode struct {
+	ID        int    `json:"id"`
+	Level     int    `json:"level"`
+	Blueprint string `json:"blueprint"`
+	Hint      string `json:"hint,omitempty"` // hint name for hint instructions
+	Outputs   []int  `json:"outputs"`        // wires solved by the instruction
+
+	// Opaque is set if the blueprint doesn't expose the wires of the instruction (neither
+	// BlueprintR1C, BlueprintHint nor BlueprintWires). The instruction has no edges, and the
+	// wires it solves are taken as solved by their first reader.
+	Opaque bool `json:"opaque,omitempty"`
+}
+
+// GraphEdge links the instruction solving Wires to an instruction reading them.
+type GraphEdge struct {
+	From  int   `json:"from"`
+	To    int   `json:"to"`
+	Wires []int `json:"wires"`
+}
+
+// DependencyGraph derives the instruction dependency graph from the wires referenced by
+// each instruction (see Iterable), annotated with the solver levels.
+func (cs *system) DependencyGraph() *DependencyGraph {
+	nbInputs := cs.GetNbPublicVariables() + cs.GetNbSecretVariables()
+	nbWires := nbInputs + cs.GetNbInternalVariables()
+
+	g := &DependencyGraph{
+		Nodes:    make([]GraphNode, len(cs.Instructions)),
+		NbLevels: len(cs.Levels),
+	}
+	for i := range g.Nodes {
+		g.Nodes[i] = GraphNode{ID: i, Level: -1}
+	}
+	for level, iIDs := range cs.Levels {
+		for _, iID := range iIDs {
+			g.Nodes[iID].Level = level
+		}
+	}
+
+	producer := make([]int, nbWires)
+	for i := range producer {
+		producer[i] = -1
+	}
+	depth := make([]int, len(cs.Instructions))       // length of the longest chain ending at the instruction
+	predecessor := make([]int, len(cs.Instructions)) // previous instruction on that chain, or -1
+	for i := range predecessor {
+		depth[i], predecessor[i] = 1, -1
+	}
+
+	var (
+		r1c R1C
+		hm  HintMapping
+	)
+	for iID, inst := range cs.Instructions {
+		node := &g.Nodes[iID]
+		blueprint := cs.Blueprints[inst.BlueprintID]
+		node.Blueprint = BlueprintName(blueprint)
+
+		// hints and custom blueprints declare the wires they solve, a R1C solves the wire
+		// it references first.
+		var it, outputs func() int
+		switch bc := blueprint.(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, cs.GetCallData(inst))
+			it = r1c.WireIterator()
+		case BlueprintHint:
+			bc.DecompressHint(&hm, cs.GetCallData(inst))
+			node.Hint = cs.MHintsDependencies[hm.HintID]
+			it = hm.WireIterator()
+			wID := int(hm.OutputRange.Start)
+			outputs = func() int {
+				if wID >= int(hm.OutputRange.End) {
+					return -1
+				}
+				wID++
+				return wID - 1
+			}
+		case BlueprintWires:
+			calldata := cs.GetCallData(inst)
+			it, outputs = bc.InputWires(calldata), bc.OutputWires(calldata)
+		default:
+			node.Opaque = true
+			continue
+		}
+		if outputs != nil {
+			for wID := outputs(); wID != -1; wID = outputs() {
+				producer[wID] = iID
+				node.Outputs = append(node.Outputs, wID)
+			}
+		}
+
+		// group the wires read by producer
+		incoming := make(map[int][]int)
+		for wID := it(); wID != -1; wID = it() {
+			if wID < nbInputs {
+				continue
+			}
+			p := producer[wID]
+			if p == -1 {
+				// first occurrence, this instruction solves the wire.
+				producer[wID] = iID
+				node.Outputs = append(node.Outputs, wID)
+				continue
+			}
+			if p == iID {
+				continue
+			}
+			if !containsInt(incoming[p], wID) {
+				incoming[p] = append(incoming[p], wID)
+			}
+		}
+
+		froms := make([]int, 0, len(incoming))
+		for p := range incoming {
+			froms = append(froms, p)
+		}
+		sort.Ints(froms)
+		for _, p := range froms {
+			g.Edges = append(g.Edges, GraphEdge{From: p, To: iID, Wires: incoming[p]})
+			if depth[p]+1 > depth[iID] {
+				depth[iID], predecessor[iID] = depth[p]+1, p
+			}
+		}
+	}
+
+	// instructions are topologically sorted, we walk back from the deepest one.
+	last := -1
+	for iID := range depth {
+		if last == -1 || depth[iID] > depth[last] {
+			last = iID
+		}
+	}
+	for iID := last; iID != -1; iID = predecessor[iID] {
+		g.CriticalPath = append(g.CriticalPath, iID)
+	}
+	for i, j := 0, len(g.CriticalPath)-1; i < j; i, j = i+1, j-1 {
+		g.CriticalPath[i], g.CriticalPath[j] = g.CriticalPath[j], g.CriticalPath[i]
+	}
+	for _, iID := range g.CriticalPath {
+		if g.Nodes[iID].Hint != "" {
+			g.CriticalPathHints = append(g.CriticalPathHints, g.Nodes[iID].Hint)
+		}
+	}
+
+	return g
+}
+
+// WriteDOT writes the graph in the graphviz DOT format. Instructions of the same level are
+// ranked together, hints are drawn as boxes, opaque instructions are dashed and the
+// critical path is highlighted.
+func (g *DependencyGraph) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	onPath := make(map[int]bool, len(g.CriticalPath))
+	pathEdges := make(map[[2]int]bool, len(g.CriticalPath))
+	for i, iID := range g.CriticalPath {
+		onPath[iID] = true
+		if i > 0 {
+			pathEdges[[2]int{g.CriticalPath[i-1], iID}] = true
+		}
+	}
+
+	bw.WriteString("digraph instructions {\n\trankdir=TB;\n\tnode [shape=ellipse, fontsize=10];\n")
+	fmt.Fprintf(bw, "\tlabel=\"%d instructions, %d levels, critical path %d\";\n", len(g.Nodes), g.NbLevels, len(g.CriticalPath))
+
+	byLevel := make(map[int][]int)
+	for _, n := range g.Nodes {
+		byLevel[n.Level] = append(byLevel[n.Level], n.ID)
+		label := "#" + strconv.Itoa(n.ID)
+		attrs := ""
+		if n.Hint != "" {
+			label += `\n` + strings.ReplaceAll(n.Hint, `"`, `\"`)
+			attrs = ", shape=box"
+		}
+		if n.Opaque {
+			attrs += ", style=dashed"
+		}
+		if onPath[n.ID] {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(bw, "\ti%d [label=\"%s\"%s];\n", n.ID, label, attrs)
+	}
+
+	levels := make([]int, 0, len(byLevel))
+	for level := range byLevel {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+	for _, level := range levels {
+		fmt.Fprintf(bw, "\tsubgraph level%d {\n\t\trank=same;\n\t\tl%d [label=\"level %d\", shape=plaintext];\n", level+1, level+1, level)
+		for _, iID := range byLevel[level] {
+			fmt.Fprintf(bw, "\t\ti%d;\n", iID)
+		}
+		bw.WriteString("\t}\n")
+	}
+	for i := 1; i < len(levels); i++ {
+		fmt.Fprintf(bw, "\tl%d -> l%d [style=invis];\n", levels[i-1]+1, levels[i]+1)
+	}
+
+	for _, e := range g.Edges {
+		attrs := ""
+		if pathEdges[[2]int{e.From, e.To}] {
+			attrs = " [color=red]"
+		}
+		fmt.Fprintf(bw, "\ti%d -> i%d%s;\n", e.From, e.To, attrs)
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
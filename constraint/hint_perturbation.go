@@ -0,0 +1,268 @@
+package cs
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+// Perturbation strategies applied to the outputs of a hint by CheckHintPerturbations.
+const (
+	PerturbRandom   = "random"    // outputs replaced by random values
+	PerturbZero     = "zero"      // outputs replaced by 0
+	PerturbOne      = "one"       // outputs replaced by 1
+	PerturbMinusOne = "minus_one" // outputs replaced by -1
+	PerturbShift    = "shift"     // outputs replaced by honest output + 1
+)
+
+var defaultPerturbations = []string{PerturbRandom, PerturbZero, PerturbOne, PerturbMinusOne, PerturbShift}
+
+// PerturbationConfig configures CheckHintPerturbations.
+type PerturbationConfig struct {
+	// Strategies to apply to the hint outputs; defaults to all strategies.
+	Strategies []string
+	// NbRandomTrials is the number of trials for PerturbRandom; defaults to 1.
+	NbRandomTrials int
+	// MaxInstances bounds the number of hint instructions checked; 0 means all.
+	MaxInstances int
+	// PerOutput enables perturbing each output of a hint individually, in addition
+	// to perturbing all of them at once.
+	PerOutput bool
+}
+
+// HintPerturbationFinding describes a hint instruction whose outputs could be replaced
+// by different values while the system remained satisfied.
+type HintPerturbationFinding struct {
+	Instruction int      `json:"instruction"` // id of the hint instruction
+	Hint        string   `json:"hint"`        // hint name, as registered in MHintsDependencies
+	Outputs     []int    `json:"outputs"`     // wires solved by the hint
+	Strategies  []string `json:"strategies"`  // strategies for which the system was still satisfied
+}
+
+// HintPerturbationReport is the result of CheckHintPerturbations.
+type HintPerturbationReport struct {
+	NbInstances int                       `json:"nbInstances"` // number of hint instructions checked
+	NbSolves    int                       `json:"nbSolves"`    // number of perturbed solver runs
+	Findings    []HintPerturbationFinding `json:"findings"`
+}
+
+// CheckHintPerturbations dynamically looks for under-constrained hints.
+//
+// The system is first solved with the provided witness. Then, for each hint instruction,
+// it is solved again with the outputs of that instruction replaced by perturbed values
+// (see PerturbationConfig.Strategies), through hintsolver.OverrideHint. If the system is
+// still satisfied with outputs differing from the honest ones, the hint outputs are not
+// fully constrained for this witness and the instruction is reported.
+//
+// The commitment hint is not perturbed; if the system has a commitment, opts must provide
+// a function for it.
+//
+// This is a costly analysis (one solver run per instance and strategy), meant to be run on
+// small witnesses before deploying a proving key.
+func (cs *system) CheckHintPerturbations(w witness.Witness, config PerturbationConfig, opts ...csolver.Option) (*HintPerturbationReport, error) {
+	if len(config.Strategies) == 0 {
+		config.Strategies = defaultPerturbations
+	}
+	if config.NbRandomTrials <= 0 {
+		config.NbRandomTrials = 1
+	}
+
+	_honest, err := cs.Solve(w, opts...)
+	if err != nil {
+		return nil, err
+	}
+	honest := _honest.(*R1CSSolution).W
+
+	hintFunctions, err := csolver.NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the overridden hint function can't know which instruction it is called for, so
+	// we identify the instances by their inputs. Instances of a hint with the same inputs
+	// form a group, and we perturb the k-th call of the group.
+	type instance struct {
+		iID        int
+		hintID     csolver.HintID
+		start, end int
+		inputs     []big.Int
+	}
+	var instances []instance
+	groups := make(map[string][]int)
+	var hm HintMapping
+	for iID, inst := range cs.Instructions {
+		bc, ok := cs.Blueprints[inst.BlueprintID].(BlueprintHint)
+		if !ok {
+			continue
+		}
+		if config.MaxInstances > 0 && len(instances) >= config.MaxInstances {
+			break
+		}
+		bc.DecompressHint(&hm, cs.GetCallData(inst))
+		if cs.CommitmentInfo.Is() && hm.HintID == cs.CommitmentInfo.HintID {
+			continue
+		}
+		if _, ok := hintFunctions.HintFunctions[hm.HintID]; !ok {
+			continue
+		}
+
+		// compute the honest inputs of this instance
+		inputs := make([]big.Int, len(hm.Inputs))
+		for i, l := range hm.Inputs {
+			var v, t fr.Element
+			for _, term := range l {
+				if term.IsConstant() {
+					v.Add(&v, &cs.Coefficients[term.CoeffID()])
+					continue
+				}
+				t.Mul(&cs.Coefficients[term.CoeffID()], &honest[term.WireID()])
+				v.Add(&v, &t)
+			}
+			v.BigInt(&inputs[i])
+		}
+		key := string(groupKey(hm.HintID, inputs))
+		groups[key] = append(groups[key], len(instances))
+		instances = append(instances, instance{
+			iID:    iID,
+			hintID: hm.HintID,
+			start:  int(hm.OutputRange.Start),
+			end:    int(hm.OutputRange.End),
+			inputs: inputs,
+		})
+	}
+
+	report := &HintPerturbationReport{NbInstances: len(instances)}
+	strategies := make([][]string, len(instances))
+	addStrategy := func(i int, strategy string) {
+		for _, s := range strategies[i] {
+			if s == strategy {
+				return
+			}
+		}
+		strategies[i] = append(strategies[i], strategy)
+	}
+
+	for i := range instances {
+		target := &instances[i]
+		group := groups[string(groupKey(target.hintID, target.inputs))]
+		k := 0
+		for k < len(group) && group[k] != i {
+			k++
+		}
+		f := hintFunctions.HintFunctions[target.hintID]
+
+		// output index to perturb, -1 for all of them
+		selected := []int{-1}
+		if config.PerOutput && target.end-target.start > 1 {
+			for o := 0; o < target.end-target.start; o++ {
+				selected = append(selected, o)
+			}
+		}
+
+		for _, strategy := range config.Strategies {
+			nbTrials := len(selected)
+			if strategy == PerturbRandom {
+				nbTrials *= config.NbRandomTrials
+			}
+			for trial := 0; trial < nbTrials; trial++ {
+				perturbed := perturbHint(f, target.inputs, k, strategy, selected[trial%len(selected)])
+				report.NbSolves++
+				// we don't go through Solve to avoid logging the expected errors.
+				s, err := newSolver(cs, w.Vector().(fr.Vector), append(opts, csolver.OverrideHint(target.hintID, perturbed))...)
+				if err != nil {
+					return nil, err
+				}
+				if err := s.run(); err != nil {
+					continue
+				}
+				// the solving order within a level is not deterministic, the perturbed
+				// call may not be the target one; we check all the instances of the group.
+				for _, j := range group {
+					for wID := instances[j].start; wID < instances[j].end; wID++ {
+						if !s.values[wID].Equal(&honest[wID]) {
+							addStrategy(j, strategy)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for i, inst := range instances {
+		if len(strategies[i]) == 0 {
+			continue
+		}
+		finding := HintPerturbationFinding{
+			Instruction: inst.iID,
+			Hint:        cs.MHintsDependencies[inst.hintID],
+			Strategies:  strategies[i],
+		}
+		for wID := inst.start; wID < inst.end; wID++ {
+			finding.Outputs = append(finding.Outputs, wID)
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	return report, nil
+}
+
+func groupKey(hintID csolver.HintID, inputs []big.Int) []byte {
+	key := make([]byte, 4, 4+len(inputs)*fr.Bytes)
+	binary.BigEndian.PutUint32(key, uint32(hintID))
+	var buf [fr.Bytes]byte
+	for i := range inputs {
+		inputs[i].FillBytes(buf[:])
+		key = append(key, buf[:]...)
+	}
+	return key
+}
+
+// perturbHint returns a hint function which behaves like f, except for the k-th call with
+// the target inputs where the outputs are perturbed according to strategy. If output is
+// not -1, only outputs[output] is perturbed.
+func perturbHint(f csolver.HintFn, target []big.Int, k int, strategy string, output int) csolver.HintFn {
+	var nbCalls int32
+	return func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		if err := f(q, inputs, outputs); err != nil {
+			return err
+		}
+		if len(inputs) != len(target) {
+			return nil
+		}
+		for i := range inputs {
+			if inputs[i].Cmp(&target[i]) != 0 {
+				return nil
+			}
+		}
+		if int(atomic.AddInt32(&nbCalls, 1))-1 != k {
+			return nil
+		}
+		for i, o := range outputs {
+			if output != -1 && i != output {
+				continue
+			}
+			switch strategy {
+			case PerturbRandom:
+				var r fr.Element
+				if _, err := r.SetRandom(); err != nil {
+					return err
+				}
+				r.BigInt(o)
+			case PerturbZero:
+				o.SetUint64(0)
+			case PerturbOne:
+				o.SetUint64(1)
+			case PerturbMinusOne:
+				o.Sub(q, big.NewInt(1))
+			case PerturbShift:
+				o.Add(o, big.NewInt(1)).Mod(o, q)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,230 @@
+package cs
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// InspectReport summarizes the size and shape of a constraint system. It is meant to size
+// prover machines and to track circuit size regressions.
+type InspectReport struct {
+	NbConstraints  int `json:"nbConstraints"`
+	NbInstructions int `json:"nbInstructions"`
+
+	// Blueprints lists the blueprints of the system and their usage.
+	Blueprints []BlueprintUsage `json:"blueprints"`
+
+	NbCoefficients int `json:"nbCoefficients"`
+	CallDataBytes  int `json:"callDataBytes"`
+
+	Levels LevelStats `json:"levels"`
+
+	// Hints maps hint names to their number of instructions.
+	Hints map[string]int `json:"hints"`
+
+	Wires WireStats `json:"wires"`
+
+	// DomainSize is the size of the FFT domain used by the Groth16 prover, the smallest
+	// power of two greater or equal to the number of constraints.
+	DomainSize uint64 `json:"domainSize"`
+
+	Memory MemoryEstimate `json:"memory"`
+}
+
+// BlueprintUsage is the number of instructions and constraints using a blueprint.
+type BlueprintUsage struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	NbInstructions int    `json:"nbInstructions"`
+	NbConstraints  int    `json:"nbConstraints"`
+}
+
+// LevelStats describes the distribution of the instructions in the solver levels.
+type LevelStats struct {
+	NbLevels int     `json:"nbLevels"`
+	MinWidth int     `json:"minWidth"`
+	MaxWidth int     `json:"maxWidth"`
+	AvgWidth float64 `json:"avgWidth"`
+	// Histogram[i] is the number of levels with a width in [2^i, 2^(i+1)).
+	Histogram []int `json:"histogram"`
+}
+
+// WireStats counts the wires of the system by kind.
+type WireStats struct {
+	Public      int `json:"public"` // including the constant one wire
+	Secret      int `json:"secret"`
+	Internal    int `json:"internal"`
+	HintOutputs int `json:"hintOutputs"` // internal wires solved by a hint
+	Committed   int `json:"committed"`
+	Total       int `json:"total"`
+}
+
+// MemoryEstimate is a rough estimate of the memory needed to generate a Groth16 proof, in
+// bytes. Points at infinity in the proving key are not accounted for, such that the
+// estimate is an upper bound of the allocated data (excluding runtime overhead).
+type MemoryEstimate struct {
+	ConstraintSystem uint64 `json:"constraintSystem"` // instructions, calldata and coefficients
+	ProvingKey       uint64 `json:"provingKey"`       // decoded proving key points
+	Solver           uint64 `json:"solver"`           // wire values and A, B, C evaluations
+	FFT              uint64 `json:"fft"`              // A, B, C extended to the domain, and H
+	Total            uint64 `json:"total"`
+}
+
+// Inspect walks the system and returns an InspectReport.
+func (cs *system) Inspect() *InspectReport {
+	nbPublic, nbSecret, nbInternal := cs.GetNbPublicVariables(), cs.GetNbSecretVariables(), cs.GetNbInternalVariables()
+	report := &InspectReport{
+		NbConstraints:  cs.GetNbConstraints(),
+		NbInstructions: len(cs.Instructions),
+		NbCoefficients: len(cs.Coefficients),
+		CallDataBytes:  4 * len(cs.CallData),
+		Hints:          make(map[string]int),
+		Wires: WireStats{
+			Public:    nbPublic,
+			Secret:    nbSecret,
+			Internal:  nbInternal,
+			Committed: cs.CommitmentInfo.NbCommitted(),
+			Total:     nbPublic + nbSecret + nbInternal,
+		},
+	}
+
+	report.Blueprints = make([]BlueprintUsage, len(cs.Blueprints))
+	for i, b := range cs.Blueprints {
+		report.Blueprints[i] = BlueprintUsage{ID: i, Name: BlueprintName(b)}
+	}
+	var hm HintMapping
+	for _, inst := range cs.Instructions {
+		usage := &report.Blueprints[inst.BlueprintID]
+		usage.NbInstructions++
+		blueprint := cs.Blueprints[inst.BlueprintID]
+		if _, ok := blueprint.(BlueprintR1C); ok {
+			usage.NbConstraints += blueprint.NbConstraints()
+		}
+		if bc, ok := blueprint.(BlueprintHint); ok {
+			bc.DecompressHint(&hm, cs.GetCallData(inst))
+			name, ok := cs.MHintsDependencies[hm.HintID]
+			if !ok {
+				name = fmt.Sprintf("%d", hm.HintID)
+			}
+			report.Hints[name]++
+			report.Wires.HintOutputs += int(hm.OutputRange.End - hm.OutputRange.Start)
+		}
+	}
+
+	report.Levels.NbLevels = len(cs.Levels)
+	for i, level := range cs.Levels {
+		w := len(level)
+		if i == 0 || w < report.Levels.MinWidth {
+			report.Levels.MinWidth = w
+		}
+		if w > report.Levels.MaxWidth {
+			report.Levels.MaxWidth = w
+		}
+		if w == 0 {
+			continue
+		}
+		bucket := bits.Len(uint(w)) - 1
+		for len(report.Levels.Histogram) <= bucket {
+			report.Levels.Histogram = append(report.Levels.Histogram, 0)
+		}
+		report.Levels.Histogram[bucket]++
+	}
+	if len(cs.Levels) != 0 {
+		nbLeveled := 0
+		for _, level := range cs.Levels {
+			nbLeveled += len(level)
+		}
+		report.Levels.AvgWidth = float64(nbLeveled) / float64(len(cs.Levels))
+	}
+
+	report.DomainSize = 1
+	for report.DomainSize < uint64(report.NbConstraints) {
+		report.DomainSize <<= 1
+	}
+
+	report.Memory = estimateMemory(report)
+	return report
+}
+
+func estimateMemory(r *InspectReport) MemoryEstimate {
+	const (
+		sizeFr      = fr.Bytes
+		sizeG1      = 2 * fr.Bytes
+		sizeG2      = 4 * fr.Bytes
+		sizeInst    = 16
+		sizeCallArg = 4
+	)
+	nbWires := uint64(r.Wires.Total)
+	nbConstraints := uint64(r.NbConstraints)
+	domain := r.DomainSize
+
+	var m MemoryEstimate
+	m.ConstraintSystem = uint64(r.NbInstructions)*sizeInst + uint64(r.CallDataBytes) + uint64(r.NbCoefficients)*sizeFr
+	// G1.A, G1.B, G1.K are indexed by wires, G1.Z by the domain, G2.B by wires.
+	m.ProvingKey = (3*nbWires+domain)*sizeG1 + nbWires*sizeG2 + 2*nbWires
+	m.Solver = (nbWires + 3*nbConstraints) * sizeFr
+	// computeH extends A, B, C to the domain and works on coset evaluations in place;
+	// the prover also copies the filtered wire values for the multi-exponentiations.
+	m.FFT = 3*domain*sizeFr + 2*nbWires*sizeFr
+	m.Total = m.ConstraintSystem + m.ProvingKey + m.Solver + m.FFT
+	return m
+}
+
+// String formats the report for humans.
+func (r *InspectReport) String() string {
+	var sbb strings.Builder
+	fmt.Fprintf(&sbb, "constraints:   %d\n", r.NbConstraints)
+	fmt.Fprintf(&sbb, "instructions:  %d\n", r.NbInstructions)
+	fmt.Fprintf(&sbb, "coefficients:  %d\n", r.NbCoefficients)
+	fmt.Fprintf(&sbb, "calldata:      %s\n", formatBytes(uint64(r.CallDataBytes)))
+	fmt.Fprintf(&sbb, "fft domain:    %d\n", r.DomainSize)
+	fmt.Fprintf(&sbb, "wires:         %d (public %d, secret %d, internal %d, hint outputs %d, committed %d)\n",
+		r.Wires.Total, r.Wires.Public, r.Wires.Secret, r.Wires.Internal, r.Wires.HintOutputs, r.Wires.Committed)
+
+	sbb.WriteString("blueprints:\n")
+	for _, b := range r.Blueprints {
+		fmt.Fprintf(&sbb, "  #%d %s: %d instructions, %d constraints\n", b.ID, b.Name, b.NbInstructions, b.NbConstraints)
+	}
+
+	fmt.Fprintf(&sbb, "levels:        %d (width min %d, max %d, avg %.1f)\n",
+		r.Levels.NbLevels, r.Levels.MinWidth, r.Levels.MaxWidth, r.Levels.AvgWidth)
+	for i, n := range r.Levels.Histogram {
+		if n != 0 {
+			fmt.Fprintf(&sbb, "  width [%d, %d): %d\n", 1<<i, 1<<(i+1), n)
+		}
+	}
+
+	if len(r.Hints) != 0 {
+		sbb.WriteString("hints:\n")
+		names := make([]string, 0, len(r.Hints))
+		for name := range r.Hints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&sbb, "  %s: %d\n", name, r.Hints[name])
+		}
+	}
+
+	fmt.Fprintf(&sbb, "estimated proving memory: %s (ccs %s, pk %s, solver %s, fft %s)\n",
+		formatBytes(r.Memory.Total), formatBytes(r.Memory.ConstraintSystem), formatBytes(r.Memory.ProvingKey),
+		formatBytes(r.Memory.Solver), formatBytes(r.Memory.FFT))
+	return sbb.String()
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,195 @@
+package cs
+
+import "fmt"
+
+// The main idea here is to find a naive clustering of independent constraints that can be solved in parallel.
+//
+// We know that at each constraint, we will have at most one unsolved wire.
+// (a constraint may have no unsolved wire in which case it is a plain check that the constraint hold,
+// or it may additionally have some wires that will be solved by solver hints)
+//
+// We build a graph of dependency; we say that a wire is solved at a level l
+// --> l = max(level_of_dependencies(wire)) + 1
+
+// Iterable is implemented by objects (usually constraints) referencing wires.
+type Iterable interface {
+	// WireIterator returns a new iterator to iterate over the wires of the implementer (usually, a constraint)
+	// Call to next() returns the next wireID of the Iterable object and -1 when iteration is over.
+	//
+	// For example a R1C constraint with L, R, O linear expressions, each of size 2, calling several times
+	// 		next := r1c.WireIterator();
+	// 		for wID := next(); wID != -1; wID = next() {}
+	//		// will return in order L[0],L[1],R[0],R[1],O[0],O[1],-1
+	WireIterator() (next func() int)
+}
+
+var _ Iterable = &R1C{}
+var _ Iterable = &HintMapping{}
+
+func (system *System) updateLevel(iID int, c Iterable) {
+	system.appendToLevel(iID, system.computeLevel(c))
+}
+
+func (system *System) appendToLevel(iID, level int) {
+	// we can't skip levels, so appending is fine.
+	if level >= len(system.Levels) {
+		system.Levels = append(system.Levels, []int{iID})
+	} else {
+		system.Levels[level] = append(system.Levels[level], iID)
+	}
+}
+
+// computeLevel returns the level at which the instruction can be solved and marks
+// its output wires as solved at that level.
+func (system *System) computeLevel(c Iterable) int {
+	level := system.dependencyLevel(c)
+	system.markOutputs(level)
+	return level
+}
+
+// dependencyLevel returns max(level of dependencies) + 1 and collects the output wires
+// of the instruction in lbOutputs.
+func (system *System) dependencyLevel(c Iterable) int {
+	level := -1
+	wireIterator := c.WireIterator()
+
+	for wID := wireIterator(); wID != -1; wID = wireIterator() {
+		// iterate over all wires of the instruction
+		system.processWire(uint32(wID), &level)
+	}
+
+	// level =  max(dependencies) + 1
+	return system.floorLevel(level + 1)
+}
+
+// floorLevel returns level, or the first level open to new instructions if it is lower
+// (see initLevelBuilder).
+func (system *System) floorLevel(level int) int {
+	if level < system.lbMinLevel {
+		return system.lbMinLevel
+	}
+	return level
+}
+
+// markOutputs marks the wires collected in lbOutputs as solved at level.
+func (system *System) markOutputs(level int) {
+	for _, wireID := range system.lbOutputs {
+		system.lbWireLevel[wireID] = level
+	}
+
+	// clean the table. NB! Do not remove or move, this is required to make the
+	// compilation deterministic.
+	system.lbOutputs = system.lbOutputs[:0]
+}
+
+// wiresDependencyLevel is dependencyLevel for the instructions of a BlueprintWires: the
+// inputs must be solved by previous instructions, and the outputs must not.
+func (system *System) wiresDependencyLevel(b BlueprintWires, calldata []uint32) (int, error) {
+	nbInputs := system.GetNbPublicVariables() + system.GetNbSecretVariables()
+	nbWires := nbInputs + system.GetNbInternalVariables()
+
+	level := -1
+	next := b.InputWires(calldata)
+	for wID := next(); wID != -1; wID = next() {
+		if wID >= nbWires {
+			return -1, fmt.Errorf("wire %d out of range", wID)
+		}
+		system.processWire(uint32(wID), &level)
+		if len(system.lbOutputs) != 0 {
+			system.lbOutputs = system.lbOutputs[:0]
+			return -1, fmt.Errorf("input wire %d is not solved by a previous instruction", wID)
+		}
+	}
+
+	next = b.OutputWires(calldata)
+	for wID := next(); wID != -1; wID = next() {
+		if wID < nbInputs || wID >= nbWires {
+			system.lbOutputs = system.lbOutputs[:0]
+			return -1, fmt.Errorf("output wire %d is not an internal wire", wID)
+		}
+		for wID >= len(system.lbWireLevel) {
+			system.lbWireLevel = append(system.lbWireLevel, -1)
+		}
+		if system.lbWireLevel[wID] != -1 {
+			system.lbOutputs = system.lbOutputs[:0]
+			return -1, fmt.Errorf("output wire %d is already solved", wID)
+		}
+		system.lbOutputs = append(system.lbOutputs, uint32(wID))
+	}
+
+	return system.floorLevel(level + 1), nil
+}
+
+func (system *System) processWire(wireID uint32, maxLevel *int) {
+	if wireID < uint32(system.GetNbPublicVariables()+system.GetNbSecretVariables()) {
+		return // ignore inputs
+	}
+	for int(wireID) >= len(system.lbWireLevel) {
+		// we didn't encounter this wire yet, we need to grow b.wireLevels
+		system.lbWireLevel = append(system.lbWireLevel, -1)
+	}
+	if system.lbWireLevel[wireID] != -1 {
+		// we know how to solve this wire, it's a dependency
+		if system.lbWireLevel[wireID] > *maxLevel {
+			*maxLevel = system.lbWireLevel[wireID]
+		}
+		return
+	}
+	// this wire is an output to the instruction
+	system.lbOutputs = append(system.lbOutputs, wireID)
+}
+
+// initLevelBuilder rebuilds the level builder state from the existing instructions.
+// It is needed when the system was built from a serialized representation and
+// more instructions are added to it afterwards. Levels are left untouched; outputs are
+// marked with the level their instruction belongs to, which may differ from the computed
+// one if levels were merged (see Optimize).
+//
+// A merged level may hold dependent instructions and must be solved sequentially: since
+// it can't be told apart from a regular one, the instructions added afterwards go to new
+// levels, after the existing ones.
+func (system *System) initLevelBuilder() {
+	system.lbWireLevel = system.lbWireLevel[:0]
+	system.lbOutputs = system.lbOutputs[:0]
+	system.lbMinLevel = 0
+
+	levelOf := make([]int, len(system.Instructions))
+	for i := range levelOf {
+		levelOf[i] = -1
+	}
+	for level, iIDs := range system.Levels {
+		for _, iID := range iIDs {
+			levelOf[iID] = level
+		}
+	}
+
+	var (
+		r1c R1C
+		hm  HintMapping
+	)
+	for iID, inst := range system.Instructions {
+		blueprint := system.Blueprints[inst.BlueprintID]
+		calldata := system.GetCallData(inst)
+		var level int
+		switch bc := blueprint.(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, calldata)
+			level = system.dependencyLevel(&r1c)
+		case BlueprintHint:
+			bc.DecompressHint(&hm, calldata)
+			level = system.dependencyLevel(&hm)
+		case BlueprintWires:
+			var err error
+			if level, err = system.wiresDependencyLevel(bc, calldata); err != nil {
+				continue
+			}
+		default:
+			continue
+		}
+		if levelOf[iID] != -1 {
+			level = levelOf[iID]
+		}
+		system.markOutputs(level)
+	}
+	system.lbMinLevel = len(system.Levels)
+}
@@ -0,0 +1,330 @@
+package cs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// OptimizeConfig selects the passes run by Optimize.
+type OptimizeConfig struct {
+	// RemoveDuplicates removes the R1C equal to a previous one (up to the order of the
+	// terms and of L and R). It changes the constraint matrix.
+	RemoveDuplicates bool
+	// DropUnusedCoefficients removes the coefficients not referenced by any instruction.
+	DropUnusedCoefficients bool
+	// CompactBlueprints rewrites the instructions to use fixed-shape blueprints, see
+	// System.CompactBlueprints.
+	CompactBlueprints bool
+	// MergeLevels merges adjacent levels small enough to be solved sequentially.
+	MergeLevels bool
+	// RenumberWires removes the internal wires referenced by no instruction and renumbers
+	// the remaining ones, keeping their relative order. It changes the constraint matrix
+	// if a wire is removed.
+	RenumberWires bool
+}
+
+// DefaultOptimizeConfig enables all the passes of Optimize.
+func DefaultOptimizeConfig() OptimizeConfig {
+	return OptimizeConfig{
+		RemoveDuplicates:       true,
+		DropUnusedCoefficients: true,
+		CompactBlueprints:      true,
+		MergeLevels:            true,
+		RenumberWires:          true,
+	}
+}
+
+// OptimizeReport summarizes the effect of Optimize.
+type OptimizeReport struct {
+	NbDuplicates int `json:"nbDuplicates"` // removed R1C
+
+	NbCoefficientsBefore int `json:"nbCoefficientsBefore"`
+	NbCoefficientsAfter  int `json:"nbCoefficientsAfter"`
+	CallDataBefore       int `json:"callDataBefore"` // bytes
+	CallDataAfter        int `json:"callDataAfter"`  // bytes
+	NbLevelsBefore       int `json:"nbLevelsBefore"`
+	NbLevelsAfter        int `json:"nbLevelsAfter"`
+	NbInternalBefore     int `json:"nbInternalBefore"`
+	NbInternalAfter      int `json:"nbInternalAfter"`
+
+	// WireMap maps the wires of the original system to the wires of the optimized one,
+	// -1 for removed wires. Public and secret wires are unchanged.
+	WireMap []int `json:"wireMap"`
+
+	// MatrixChanged is set if the constraint matrix of the optimized system differs from
+	// the original one, in which case a new setup is required.
+	MatrixChanged bool `json:"matrixChanged"`
+
+	// NbCustom is the number of instructions of custom blueprints (BlueprintWires), copied
+	// unchanged. Their calldata can't be rewritten: if there are any, the coefficients and
+	// the wires are neither dropped nor renumbered.
+	NbCustom int `json:"nbCustom"`
+}
+
+// Optimize returns an optimized copy of the system, leaving the system unchanged.
+//
+// The instructions are re-encoded in order, such that the calldata of the new system is
+// compact, and its levels are rebuilt. Public and secret wires keep their ids, so
+// existing witnesses still apply. The symbol table, if any, is not carried over.
+//
+// The instructions of custom blueprints are copied unchanged (see OptimizeReport.NbCustom).
+// An error is returned if a blueprint doesn't expose the wires of its instructions (neither
+// BlueprintR1C, BlueprintHint nor BlueprintWires).
+func (cs *system) Optimize(config OptimizeConfig) (*R1CS, *OptimizeReport, error) {
+	nbInputs := cs.GetNbPublicVariables() + cs.GetNbSecretVariables()
+	nbWires := nbInputs + cs.GetNbInternalVariables()
+
+	report := &OptimizeReport{
+		NbCoefficientsBefore: len(cs.Coefficients),
+		CallDataBefore:       4 * len(cs.CallData),
+		NbLevelsBefore:       len(cs.Levels),
+		NbInternalBefore:     cs.GetNbInternalVariables(),
+	}
+
+	// first pass: find duplicates, used coefficients and used wires.
+	keep := make([]bool, len(cs.Instructions))
+	usedCoeffs := make([]bool, len(cs.Coefficients))
+	usedWires := make([]bool, nbWires)
+	seen := make(map[string]struct{})
+	markUsed := func(l LinearExpression) {
+		for _, t := range l {
+			usedCoeffs[t.CoeffID()] = true
+			if !t.IsConstant() {
+				usedWires[t.WireID()] = true
+			}
+		}
+	}
+
+	var (
+		r1c R1C
+		hm  HintMapping
+	)
+	for iID, inst := range cs.Instructions {
+		switch bc := cs.Blueprints[inst.BlueprintID].(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, cs.GetCallData(inst))
+			if config.RemoveDuplicates {
+				key := cs.r1cKey(&r1c)
+				if _, ok := seen[key]; ok {
+					report.NbDuplicates++
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+			markUsed(r1c.L)
+			markUsed(r1c.R)
+			markUsed(r1c.O)
+		case BlueprintHint:
+			bc.DecompressHint(&hm, cs.GetCallData(inst))
+			for _, l := range hm.Inputs {
+				markUsed(l)
+			}
+			for wID := hm.OutputRange.Start; wID < hm.OutputRange.End; wID++ {
+				usedWires[wID] = true
+			}
+		case BlueprintWires:
+			report.NbCustom++
+		default:
+			return nil, nil, fmt.Errorf("instruction %d: blueprint %s doesn't expose its wires", iID, BlueprintName(cs.Blueprints[inst.BlueprintID]))
+		}
+		keep[iID] = true
+	}
+	for _, wID := range cs.CommitmentInfo.CommittedAndCommitment {
+		usedWires[wID] = true
+	}
+
+	// coefficient and wire maps
+	coeffMap := make([]uint32, len(cs.Coefficients))
+	out := &system{
+		System:     NewSystem(cs.Field(), len(cs.Instructions), cs.Type),
+		CoeffTable: CoeffTable{Coefficients: make([]fr.Element, 0, len(cs.Coefficients))},
+	}
+	out.GnarkVersion = cs.GnarkVersion
+	for cID := range cs.Coefficients {
+		if config.DropUnusedCoefficients && report.NbCustom == 0 && cID > CoeffIdMinusTwo && !usedCoeffs[cID] {
+			continue
+		}
+		coeffMap[cID] = uint32(len(out.Coefficients))
+		out.Coefficients = append(out.Coefficients, cs.Coefficients[cID])
+	}
+
+	report.WireMap = make([]int, nbWires)
+	nbInternal := 0
+	for wID := range report.WireMap {
+		switch {
+		case wID < nbInputs:
+			report.WireMap[wID] = wID
+		case config.RenumberWires && report.NbCustom == 0 && !usedWires[wID]:
+			report.WireMap[wID] = -1
+		default:
+			report.WireMap[wID] = nbInputs + nbInternal
+			nbInternal++
+		}
+	}
+	report.MatrixChanged = report.NbDuplicates != 0 || nbInternal != cs.GetNbInternalVariables()
+
+	// nextWire returns the new id of the first kept wire from wID, or the number of wires.
+	nextWire := func(wID int) int {
+		for ; wID < nbWires; wID++ {
+			if report.WireMap[wID] != -1 {
+				return report.WireMap[wID]
+			}
+		}
+		return nbInputs + nbInternal
+	}
+
+	remap := func(l LinearExpression) {
+		for i := range l {
+			l[i].CID = coeffMap[l[i].CID]
+			if !l[i].IsConstant() {
+				l[i].VID = uint32(report.WireMap[l[i].VID])
+			}
+		}
+	}
+
+	// second pass: re-encode the kept instructions.
+	out.Public = append([]string(nil), cs.Public...)
+	out.Secret = append([]string(nil), cs.Secret...)
+	out.NbInternalVariables = nbInternal
+	out.Blueprints = append(out.Blueprints[:0], cs.Blueprints...)
+	out.lbWireLevel = nil
+	out.initBuilder()
+	out.MHintsDependencies = make(map[hintsolver.HintID]string, len(cs.MHintsDependencies))
+	for id, name := range cs.MHintsDependencies {
+		out.MHintsDependencies[id] = name
+	}
+
+	for iID, inst := range cs.Instructions {
+		if !keep[iID] {
+			continue
+		}
+		switch bc := cs.Blueprints[inst.BlueprintID].(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, cs.GetCallData(inst))
+			remap(r1c.L)
+			remap(r1c.R)
+			remap(r1c.O)
+			out.Instructions = append(out.Instructions, out.compressR1C(&r1c, inst.BlueprintID))
+			out.updateLevel(len(out.Instructions)-1, &r1c)
+		case BlueprintHint:
+			bc.DecompressHint(&hm, cs.GetCallData(inst))
+			for _, l := range hm.Inputs {
+				remap(l)
+			}
+			if hm.OutputRange.Start == hm.OutputRange.End {
+				// no output: the range is empty at the position of the next kept wire.
+				hm.OutputRange.Start = uint32(nextWire(int(hm.OutputRange.Start)))
+				hm.OutputRange.End = hm.OutputRange.Start
+			} else {
+				// the outputs are used, hence kept and contiguous.
+				hm.OutputRange.Start = uint32(report.WireMap[hm.OutputRange.Start])
+				hm.OutputRange.End = uint32(report.WireMap[hm.OutputRange.End-1]) + 1
+			}
+			out.Instructions = append(out.Instructions, out.compressHint(hm, inst.BlueprintID))
+			out.updateLevel(len(out.Instructions)-1, &hm)
+		default:
+			// wires and coefficients are not renumbered
+			if _, err := out.AddInstruction(inst.BlueprintID, cs.GetCallData(inst)); err != nil {
+				return nil, nil, fmt.Errorf("instruction %d: %w", iID, err)
+			}
+		}
+	}
+
+	if cs.CommitmentInfo.Is() {
+		c := cs.CommitmentInfo
+		out.CommitmentInfo = Commitment{
+			Committed:              remapWires(c.Committed, report.WireMap),
+			NbPrivateCommitted:     c.NbPrivateCommitted,
+			HintID:                 c.HintID,
+			CommitmentIndex:        report.WireMap[c.CommitmentIndex],
+			CommittedAndCommitment: remapWires(c.CommittedAndCommitment, report.WireMap),
+		}
+	}
+
+	if config.CompactBlueprints {
+		out.CompactBlueprints()
+	}
+	if config.MergeLevels {
+		out.mergeLevels()
+	}
+
+	report.NbCoefficientsAfter = len(out.Coefficients)
+	report.CallDataAfter = 4 * len(out.CallData)
+	report.NbLevelsAfter = len(out.Levels)
+	report.NbInternalAfter = out.NbInternalVariables
+	return out, report, nil
+}
+
+// mergeLevels merges adjacent levels as long as the merged level is small enough to be
+// solved sequentially by the solver; instructions keep their dependency order.
+func (system *System) mergeLevels() {
+	merged := system.Levels[:0]
+	for _, level := range system.Levels {
+		if n := len(merged); n != 0 && float64(len(merged[n-1])+len(level)) <= minWorkPerCPU {
+			merged[n-1] = append(merged[n-1], level...)
+			continue
+		}
+		merged = append(merged, level)
+	}
+	system.Levels = merged
+	// the level builder state refers to the previous levels; it is rebuilt such that
+	// instructions added afterwards go to new levels (see initLevelBuilder).
+	system.lbWireLevel = nil
+}
+
+// r1cKey returns a canonical representation of a R1C, independent of the order of the
+// terms and of L and R.
+func (cs *system) r1cKey(r1c *R1C) string {
+	return cs.canonicalR1C(r1c, func(vID uint32) string {
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], vID)
+		return string(b[:])
+	})
+}
+
+// canonicalR1C returns a representation of a R1C independent of the order of the terms and
+// of L and R, with the wires encoded by wireKey. Terms are sorted by wire key, then
+// coefficient.
+func (cs *system) canonicalR1C(r1c *R1C, wireKey func(vID uint32) string) string {
+	type term struct {
+		wire  string
+		coeff [fr.Bytes]byte
+	}
+	encode := func(l LinearExpression) string {
+		sorted := make([]term, len(l))
+		for i, t := range l {
+			sorted[i] = term{wire: wireKey(t.VID), coeff: cs.Coefficients[t.CID].Bytes()}
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].wire != sorted[j].wire {
+				return sorted[i].wire < sorted[j].wire
+			}
+			return string(sorted[i].coeff[:]) < string(sorted[j].coeff[:])
+		})
+		b := make([]byte, 4, 4+len(sorted)*(8+fr.Bytes))
+		binary.BigEndian.PutUint32(b, uint32(len(sorted)))
+		for _, t := range sorted {
+			b = binary.BigEndian.AppendUint32(b, uint32(len(t.wire)))
+			b = append(b, t.wire...)
+			b = append(b, t.coeff[:]...)
+		}
+		return string(b)
+	}
+	l, r := encode(r1c.L), encode(r1c.R)
+	if r < l {
+		l, r = r, l
+	}
+	return l + r + encode(r1c.O)
+}
+
+func remapWires(wires []int, wireMap []int) []int {
+	r := make([]int, len(wires))
+	for i, wID := range wires {
+		r[i] = wireMap[wID]
+	}
+	return r
+}
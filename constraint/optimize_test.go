@@ -0,0 +1,121 @@
+package cs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// optimizeTestSystem returns a system with a duplicate constraint, an unused coefficient,
+// an unused internal wire and hints with an empty output range, one of them at the end of
+// the wires.
+func optimizeTestSystem(t *testing.T) *R1CS {
+	r := NewR1CS(16)
+	r.AddPublicVariable("1")
+	y := uint32(r.AddPublicVariable("Y"))
+	x := uint32(r.AddSecretVariable("X"))
+	g := r.AddBlueprint(&BlueprintGenericR1C{})
+	one := Term{CID: CoeffIdOne, VID: 0}
+
+	r.CoeffTable.AddCoeff(r.FromInterface(12345)) // unused
+	r.AddInternalVariable()                       // unused, before the hint outputs
+
+	hintID := csolver.GetHintID("encoding_test_bits")
+	r.MHintsDependencies[hintID] = "encoding_test_bits"
+	emptyHint := func(wID uint32) {
+		hm := HintMapping{
+			HintID: hintID,
+			Inputs: []LinearExpression{{{CID: CoeffIdOne, VID: y}}},
+		}
+		hm.OutputRange.Start, hm.OutputRange.End = wID, wID
+		// the compressed calldata is a shared buffer, reused while computing the level.
+		calldata := append([]uint32(nil), (&BlueprintGenericHint{}).CompressHint(hm)...)
+		if _, err := r.AddInstruction(r.genericHint, calldata); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emptyHint(3) // at the unused wire
+
+	// Y = b0 + 2*b1, with boolean bits
+	b, err := r.AddHint("encoding_test_bits", []LinearExpression{{{CID: CoeffIdOne, VID: y}}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, bit := range b {
+		r.AddR1C(R1C{
+			L: LinearExpression{{CID: CoeffIdOne, VID: uint32(bit)}},
+			R: LinearExpression{one, {CID: CoeffIdMinusOne, VID: uint32(bit)}},
+			O: LinearExpression{{CID: CoeffIdZero, VID: 0}},
+		}, g)
+	}
+	sum := R1C{
+		L: LinearExpression{{CID: CoeffIdOne, VID: uint32(b[0])}, {CID: CoeffIdTwo, VID: uint32(b[1])}},
+		R: LinearExpression{one},
+		O: LinearExpression{{CID: CoeffIdOne, VID: y}},
+	}
+	r.AddR1C(sum, g)
+	r.AddR1C(sum, g) // duplicate
+
+	// X ⋅ Y == Y
+	r.AddR1C(R1C{L: LinearExpression{{CID: CoeffIdOne, VID: x}}, R: LinearExpression{{CID: CoeffIdOne, VID: y}}, O: LinearExpression{{CID: CoeffIdOne, VID: y}}}, g)
+
+	emptyHint(uint32(r.GetNbPublicVariables() + r.GetNbSecretVariables() + r.GetNbInternalVariables()))
+	return r
+}
+
+func TestOptimize(t *testing.T) {
+	// the unused wire is never solved: the original system can't be solved.
+	r := optimizeTestSystem(t)
+	w := gnarkTestWitness(t, 1, 3)
+
+	opt, report, err := r.Optimize(DefaultOptimizeConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the original witness still solves the system
+	if _, err := opt.Solve(w); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := opt.Solve(gnarkTestWitness(t, 2, 3)); err == nil {
+		t.Fatal("expected an unsatisfied constraint")
+	}
+
+	// wires 0 to 2 are the inputs, 3 is unused, 4 and 5 are the hint outputs
+	if expected := []int{0, 1, 2, -1, 3, 4}; !reflect.DeepEqual(report.WireMap, expected) {
+		t.Fatalf("wire map %v, expected %v", report.WireMap, expected)
+	}
+	if !report.MatrixChanged || report.NbDuplicates != 1 {
+		t.Fatalf("expected a changed matrix and 1 duplicate, got %+v", report)
+	}
+	if report.NbCoefficientsAfter != report.NbCoefficientsBefore-1 {
+		t.Fatalf("expected the unused coefficient to be dropped, got %+v", report)
+	}
+	if report.NbLevelsAfter >= report.NbLevelsBefore {
+		t.Fatalf("expected levels to be merged, got %+v", report)
+	}
+
+	// the optimized system survives serialization
+	var buf bytes.Buffer
+	if _, err := opt.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded R1CS
+	if _, err := decoded.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decoded.Solve(w); err != nil {
+		t.Fatal(err)
+	}
+
+	// optimizing again is a no-op on the matrix
+	_, report, err = decoded.Optimize(DefaultOptimizeConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MatrixChanged || !reflect.DeepEqual(report.WireMap, []int{0, 1, 2, 3, 4}) {
+		t.Fatalf("expected an unchanged matrix, got %+v", report)
+	}
+}
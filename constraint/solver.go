@@ -18,6 +18,8 @@ import (
 	"errors"
 	"fmt"
 	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/utils"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
 	"math"
 	"math/big"
 	"runtime"
@@ -43,15 +45,48 @@ type solver struct {
 	// maps hintID to hint function
 	mHintsFunctions map[csolver.HintID]csolver.HintFn
 
+	// maps hintID to native hint function, used instead of mHintsFunctions
+	mNativeHintsFunctions map[csolver.HintID]csolver.NativeHintFn
+
 	// used to out api.Println
 	logger zerolog.Logger
 
 	a, b, c fr.Vector // R1CS solver will compute the a,b,c matrices
 
 	q *big.Int
+
+	// wipe intermediate values, see csolver.WithZeroization
+	zeroize bool
 }
 
 func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver, error) {
+	return initSolver(cs, len(witness), func(values []fr.Element) error {
+		copy(values, witness)
+		return nil
+	}, opts...)
+}
+
+// newSolverFrom decodes the witness directly into the solver values.
+func newSolverFrom(cs *system, dec *witness.Decoder, opts ...csolver.Option) (*solver, error) {
+	nbPublic, nbSecret := dec.Size()
+	if expected, _ := cs.WitnessSize(); nbPublic != expected {
+		return nil, fmt.Errorf("invalid witness, got %d public values, expected %d", nbPublic, expected)
+	}
+	return initSolver(cs, nbPublic+nbSecret, func(values []fr.Element) error {
+		for len(values) > 0 {
+			k, err := dec.Read(values)
+			if err != nil {
+				return err
+			}
+			values = values[k:]
+		}
+		return nil
+	}, opts...)
+}
+
+// initSolver allocates the solver of a system with a witness of witnessSize values, which
+// are set by fill.
+func initSolver(cs *system, witnessSize int, fill func(values []fr.Element) error, opts ...csolver.Option) (*solver, error) {
 	// parse options
 	opt, err := csolver.NewConfig(opts...)
 	if err != nil {
@@ -67,8 +102,8 @@ func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver,
 	nbWires := len(cs.Public) + len(cs.Secret) + cs.NbInternalVariables
 	expectedWitnessSize := len(cs.Public) - witnessOffset + len(cs.Secret)
 
-	if len(witness) != expectedWitnessSize {
-		return nil, fmt.Errorf("invalid witness size, got %d, expected %d", len(witness), expectedWitnessSize)
+	if witnessSize != expectedWitnessSize {
+		return nil, fmt.Errorf("invalid witness size, got %d, expected %d", witnessSize, expectedWitnessSize)
 	}
 
 	// check all hints are there
@@ -79,6 +114,12 @@ func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver,
 	for hintUUID, hintID := range cs.MHintsDependencies {
 		if _, ok := hintFunctions[hintUUID]; !ok {
 			missing = append(missing, hintID)
+			continue
+		}
+		// the ids are 32-bit hashes of the names, make sure we don't solve a hint with
+		// a function registered under another name.
+		if name, ok := opt.HintNames[hintUUID]; ok && name != hintID {
+			return nil, fmt.Errorf("%w: solver hint %s has the id of %s", csolver.ErrHintCollision, name, hintID)
 		}
 	}
 
@@ -87,12 +128,14 @@ func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver,
 	}
 
 	s := solver{
-		system:          cs,
-		values:          make([]fr.Element, nbWires),
-		solved:          make([]bool, nbWires),
-		mHintsFunctions: hintFunctions,
-		logger:          opt.Logger,
-		q:               cs.Field(),
+		system:                cs,
+		values:                make([]fr.Element, nbWires),
+		solved:                make([]bool, nbWires),
+		mHintsFunctions:       hintFunctions,
+		mNativeHintsFunctions: opt.NativeHintFunctions,
+		logger:                opt.Logger,
+		q:                     cs.Field(),
+		zeroize:               opt.Zeroize,
 	}
 
 	// set the witness indexes as solved
@@ -100,14 +143,19 @@ func newSolver(cs *system, witness fr.Vector, opts ...csolver.Option) (*solver,
 		s.solved[0] = true // ONE_WIRE
 		s.values[0].SetOne()
 	}
-	copy(s.values[witnessOffset:], witness)
-	for i := range witness {
+	if err := fill(s.values[witnessOffset : witnessOffset+witnessSize]); err != nil {
+		if s.zeroize {
+			utils.Zeroize(s.values)
+		}
+		return nil, err
+	}
+	for i := 0; i < witnessSize; i++ {
 		s.solved[i+witnessOffset] = true
 	}
 
 	// keep track of the number of wire instantiations we do, for a post solve sanity check
 	// to ensure we instantiated all wires
-	s.nbSolved += uint64(len(witness) + witnessOffset)
+	s.nbSolved += uint64(witnessSize + witnessOffset)
 
 	if s.Type == ConstrainSystemTypeR1CS {
 		n := ecc.NextPowerOfTwo(uint64(cs.GetNbConstraints()))
@@ -180,7 +228,11 @@ func (s *solver) accumulateInto(t Term, r *fr.Element) {
 }
 
 // solveWithHint executes a hint and assign the result to its defined outputs.
-func (s *solver) solveWithHint(h *HintMapping) error {
+func (s *solver) solveWithHint(h *HintMapping, scratch *scratch) error {
+	if f, ok := s.mNativeHintsFunctions[h.HintID]; ok {
+		return s.solveWithNativeHint(f, h, scratch)
+	}
+
 	// ensure hint function was provided
 	f, ok := s.mHintsFunctions[h.HintID]
 	if !ok {
@@ -219,10 +271,16 @@ func (s *solver) solveWithHint(h *HintMapping) error {
 	for i := range outputs {
 		v.SetBigInt(outputs[i])
 		s.set(int(h.OutputRange.Start)+i, v)
+		if s.zeroize {
+			utils.ZeroizeBigInt(outputs[i])
+		}
 		pool.BigInt.Put(outputs[i])
 	}
 
 	for i := range inputs {
+		if s.zeroize {
+			utils.ZeroizeBigInt(inputs[i])
+		}
 		pool.BigInt.Put(inputs[i])
 	}
 
@@ -231,6 +289,49 @@ func (s *solver) solveWithHint(h *HintMapping) error {
 	return err
 }
 
+// solveWithNativeHint executes a native hint and assign the result to its defined outputs.
+func (s *solver) solveWithNativeHint(f csolver.NativeHintFn, h *HintMapping, scratch *scratch) error {
+	nbOutputs := int(h.OutputRange.End - h.OutputRange.Start)
+	n := len(h.Inputs) + nbOutputs
+	if cap(scratch.tValues) < n {
+		scratch.tValues = make([]fr.Element, n)
+	}
+	values := scratch.tValues[:n]
+	for i := range values {
+		values[i].SetZero()
+	}
+	inputs, outputs := values[:len(h.Inputs)], values[len(h.Inputs):]
+
+	for i := range h.Inputs {
+		for _, term := range h.Inputs[i] {
+			if term.IsConstant() {
+				inputs[i].Add(&inputs[i], &s.Coefficients[term.CoeffID()])
+				continue
+			}
+			s.accumulateInto(term, &inputs[i])
+		}
+	}
+
+	err := f(inputs, outputs)
+
+	for i := range outputs {
+		s.set(int(h.OutputRange.Start)+i, outputs[i])
+	}
+	if s.zeroize {
+		utils.Zeroize(values)
+	}
+
+	return err
+}
+
+// destroy wipes the wire values and the a, b, c vectors of the solver.
+func (s *solver) destroy() {
+	utils.Zeroize(s.values)
+	utils.Zeroize(s.a)
+	utils.Zeroize(s.b)
+	utils.Zeroize(s.c)
+}
+
 const unsolvedVariable = "<unsolved>"
 
 // divByCoeff sets res = res / t.Coeff
@@ -250,6 +351,8 @@ func (solver *solver) divByCoeff(res *fr.Element, cID uint32) {
 	}
 }
 
+var _ Solver = &solver{}
+
 // Implement constraint.Solver
 func (s *solver) GetValue(cID, vID uint32) Element {
 	var r Element
@@ -270,6 +373,20 @@ func (s *solver) IsSolved(vID uint32) bool {
 	return s.solved[vID]
 }
 
+// solveInstruction processes the instruction iID, and wraps the errors of constraints with
+// their debug info.
+func (solver *solver) solveInstruction(iID int, scratch *scratch) error {
+	inst := solver.Instructions[iID]
+	err := solver.processInstruction(inst, scratch)
+	if err == nil {
+		return nil
+	}
+	if _, ok := solver.Blueprints[inst.BlueprintID].(BlueprintHint); ok {
+		return err
+	}
+	return solver.wrapErrWithDebugInfo(iID, inst.ConstraintOffset, err)
+}
+
 // processInstruction decodes the instruction and execute blueprint-defined logic.
 // an instruction can encode a hint, a custom constraint or a generic constraint.
 func (solver *solver) processInstruction(inst Instruction, scratch *scratch) error {
@@ -278,6 +395,31 @@ func (solver *solver) processInstruction(inst Instruction, scratch *scratch) err
 	calldata := solver.GetCallData(inst)
 	cID := inst.ConstraintOffset // here we have 1 constraint in the instruction only
 
+	// blueprint knows how to solve itself; if it also encodes a R1C, we use it
+	// to check the constraint and compute the a, b, c rows once all wires are solved.
+	if bs, ok := blueprint.(BlueprintSolvable); ok {
+		if err := bs.Solve(solver, calldata); err != nil {
+			return err
+		}
+		if bc, ok := blueprint.(BlueprintR1C); ok {
+			bc.DecompressR1C(&scratch.tR1C, calldata)
+			return solver.solveR1C(cID, &scratch.tR1C)
+		}
+		return nil
+	}
+
+	// fixed-shape blueprints have a fast path for their common cases.
+	switch blueprint.(type) {
+	case *BlueprintBooleanR1C:
+		if solver.solved[calldata[1]] {
+			return solver.solveBooleanR1C(cID, calldata)
+		}
+	case *BlueprintMulR1C:
+		if solver.solved[calldata[1]] && solver.solved[calldata[3]] && !solver.solved[calldata[5]] {
+			return solver.solveMulR1C(cID, calldata)
+		}
+	}
+
 	if bc, ok := blueprint.(BlueprintR1C); ok {
 		// TODO @gbotrel we use the solveR1C method for now, having user-defined
 		// blueprint for R1CS would require constraint.Solver interface to add methods
@@ -290,20 +432,20 @@ func (solver *solver) processInstruction(inst Instruction, scratch *scratch) err
 	// TODO @gbotrel may be worth it to move hint logic in blueprint "solve"
 	if bc, ok := blueprint.(BlueprintHint); ok {
 		bc.DecompressHint(&scratch.tHint, calldata)
-		return solver.solveWithHint(&scratch.tHint)
+		return solver.solveWithHint(&scratch.tHint, scratch)
 	}
 
-	return nil
+	return fmt.Errorf("blueprint %d can't be solved", inst.BlueprintID)
 }
 
 // run runs the solver. it return an error if a constraint is not satisfied or if not all wires
 // were instantiated.
-func (solver *solver) run() error {
-	// minWorkPerCPU is the minimum target number of constraint a task should hold
-	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
-	// sequentially without sync.
-	const minWorkPerCPU = 50.0 // TODO @gbotrel revisit that with blocks.
+// minWorkPerCPU is the minimum target number of constraint a task should hold
+// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
+// sequentially without sync.
+const minWorkPerCPU = 50.0 // TODO @gbotrel revisit that with blocks.
 
+func (solver *solver) run() error {
 	// cs.Levels has a list of levels, where all constraints in a level l(n) are independent
 	// and may only have dependencies on previous levels
 	// for each constraint
@@ -323,7 +465,7 @@ func (solver *solver) run() error {
 			var scratch scratch
 			for t := range chTasks {
 				for _, i := range t {
-					if err := solver.processInstruction(solver.Instructions[i], &scratch); err != nil {
+					if err := solver.solveInstruction(i, &scratch); err != nil {
 						chError <- err
 						wg.Done()
 						return
@@ -351,7 +493,7 @@ func (solver *solver) run() error {
 		if maxCPU <= 1.0 {
 			// we do it sequentially
 			for _, i := range level {
-				if err := solver.processInstruction(solver.Instructions[i], &scratch); err != nil {
+				if err := solver.solveInstruction(i, &scratch); err != nil {
 					return err
 				}
 			}
@@ -500,6 +642,37 @@ func (solver *solver) solveR1C(cID uint32, r *R1C) error {
 	return nil
 }
 
+// solveBooleanR1C checks a constraint encoded by BlueprintBooleanR1C,
+// cL⋅x ⋅ (1 + cR⋅x) == 0, where x is solved.
+func (solver *solver) solveBooleanR1C(cID uint32, calldata []uint32) error {
+	a, b, c := &solver.a[cID], &solver.b[cID], &solver.c[cID]
+	*a = solver.computeTerm(Term{CID: calldata[0], VID: calldata[1]})
+	*b = solver.computeTerm(Term{CID: calldata[2], VID: calldata[1]})
+	b.Add(b, &solver.values[0])
+	c.SetZero()
+
+	var check fr.Element
+	if !check.Mul(a, b).IsZero() {
+		return fmt.Errorf("%s ⋅ %s != %s", a.String(), b.String(), c.String())
+	}
+	return nil
+}
+
+// solveMulR1C solves the output wire of a constraint encoded by BlueprintMulR1C,
+// cL⋅l ⋅ cR⋅r == cO⋅o, where l and r are solved and o is not.
+func (solver *solver) solveMulR1C(cID uint32, calldata []uint32) error {
+	a, b, c := &solver.a[cID], &solver.b[cID], &solver.c[cID]
+	*a = solver.computeTerm(Term{CID: calldata[0], VID: calldata[1]})
+	*b = solver.computeTerm(Term{CID: calldata[2], VID: calldata[3]})
+	c.Mul(a, b)
+
+	// c is the term (coeff * value), in the solver we want to store the value only
+	wire := *c
+	solver.divByCoeff(&wire, calldata[4])
+	solver.set(int(calldata[5]), wire)
+	return nil
+}
+
 // UnsatisfiedConstraintError wraps an error with useful metadata on the unsatisfied constraint
 type UnsatisfiedConstraintError struct {
 	Err       error
@@ -514,8 +687,27 @@ func (r *UnsatisfiedConstraintError) Error() string {
 	return fmt.Sprintf("constraint #%d is not satisfied: %s", r.CID, r.Err.Error())
 }
 
+func (r *UnsatisfiedConstraintError) Unwrap() error {
+	return r.Err
+}
+
+func (solver *solver) wrapErrWithDebugInfo(iID int, cID uint32, err error) *UnsatisfiedConstraintError {
+	var debugInfo *string
+	if entry, ok := solver.debugEntry(iID); ok {
+		debugInfo = new(string)
+		*debugInfo = solver.SymbolTable.resolve(entry, solver.Coefficients, func(t Term) (fr.Element, bool) {
+			if !solver.solved[t.WireID()] {
+				return fr.Element{}, false
+			}
+			return solver.computeTerm(t), true
+		})
+	}
+	return &UnsatisfiedConstraintError{CID: int(cID), Err: err, DebugInfo: debugInfo}
+}
+
 // temporary variables to avoid memallocs in hotloop
 type scratch struct {
-	tR1C  R1C
-	tHint HintMapping
+	tR1C    R1C
+	tHint   HintMapping
+	tValues []fr.Element // native hint inputs and outputs
 }
@@ -0,0 +1,147 @@
+package cs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints/math/bits"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints/selector"
+	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+// bitsTestSystem returns a system decomposing n secret inputs in nbBits bits, each bit
+// being constrained boolean, and feeding the bits to the selector hints when selectors is
+// set.
+func bitsTestSystem(tb testing.TB, n, nbBits int, selectors bool) *R1CS {
+	r := NewR1CS(n * (nbBits + 2))
+	r.AddPublicVariable("1")
+	g := r.AddBlueprint(&BlueprintGenericR1C{})
+	one := Term{CID: CoeffIdOne, VID: 0}
+	term := func(wID int) LinearExpression {
+		return LinearExpression{{CID: CoeffIdOne, VID: uint32(wID)}}
+	}
+
+	xs := make([]int, n)
+	for i := range xs {
+		xs[i] = r.AddSecretVariable(fmt.Sprintf("X%d", i))
+	}
+	for _, x := range xs {
+		b, err := r.AddHint("n_bits", []LinearExpression{term(x)}, nbBits)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		// x == Σ 2^j⋅b_j, b_j⋅b_j == b_j
+		sum := make(LinearExpression, 0, nbBits)
+		for j, bit := range b {
+			cID := r.CoeffTable.AddCoeff(r.FromInterface(uint64(1) << j))
+			sum = append(sum, Term{CID: cID, VID: uint32(bit)})
+			r.AddR1C(R1C{L: term(bit), R: term(bit), O: term(bit)}, g)
+		}
+		r.AddR1C(R1C{L: sum, R: LinearExpression{one}, O: term(x)}, g)
+
+		var outputs []int
+		ib, err := r.AddHint("ith_bit", []LinearExpression{term(x), term(b[2])}, 1)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		outputs = append(outputs, ib...)
+		if selectors {
+			mux, err := r.AddHint("mux_indicators", []LinearExpression{term(b[0])}, 4)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			step, err := r.AddHint("step_output", []LinearExpression{term(b[1]), term(x), term(b[0])}, 4)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			m, err := r.AddHint("map_indicators", []LinearExpression{term(b[0]), term(b[1]), term(b[3])}, 2)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			outputs = append(append(append(outputs, mux...), step...), m...)
+		}
+		for _, o := range outputs {
+			r.AddR1C(R1C{L: term(o), R: LinearExpression{one}, O: term(o)}, g)
+		}
+	}
+	return r
+}
+
+func bitsTestWitness(tb testing.TB, n int) witness.Witness {
+	w, err := witness.New()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	values := make(chan any, n)
+	for i := 0; i < n; i++ {
+		values <- uint64(i)*0x9e3779b97f4a7c15 + 3
+	}
+	close(values)
+	if err := w.Fill(0, n, values); err != nil {
+		tb.Fatal(err)
+	}
+	return w
+}
+
+// bigIntHints returns the solver options replacing the bits and selector hints by their
+// big.Int implementations.
+func bigIntHints() []csolver.Option {
+	var opts []csolver.Option
+	for _, h := range append(bits.GetHints(), selector.GetHints()...) {
+		opts = append(opts, csolver.OverrideHint(h.ID, h.Fn))
+	}
+	return opts
+}
+
+func TestSolveNativeHints(t *testing.T) {
+	r := bitsTestSystem(t, 100, 254, true)
+	w := bitsTestWitness(t, 100)
+	native, err := r.Solve(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bigInt, err := r.Solve(w, bigIntHints()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := native.(*R1CSSolution).W, bigInt.(*R1CSSolution).W
+	for i := range a {
+		if !a[i].Equal(&b[i]) {
+			t.Fatalf("wire %d: native %s, big.Int %s", i, a[i].String(), b[i].String())
+		}
+	}
+}
+
+func benchmarkSolve(b *testing.B, n, nbBits int, selectors bool) {
+	r := bitsTestSystem(b, n, nbBits, selectors)
+	w := bitsTestWitness(b, n)
+	for _, bc := range []struct {
+		name string
+		opts []csolver.Option
+	}{
+		{"native", nil},
+		{"bigint", bigIntHints()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Solve(w, bc.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSolveBits(b *testing.B) {
+	benchmarkSolve(b, 1000, 64, false)
+}
+
+func BenchmarkSolveBitsSelectors(b *testing.B) {
+	benchmarkSolve(b, 1000, 64, true)
+}
+
+func BenchmarkSolveBitsFull(b *testing.B) {
+	benchmarkSolve(b, 200, 254, false)
+}
@@ -15,8 +15,8 @@
 package cs
 
 import (
-	"encoding/gob"
 	csolver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+	"github.com/vocdoni/gnark-tiny-prover-g16/utils"
 	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
 	"io"
 	"time"
@@ -54,13 +54,33 @@ func newSystem(capacity int, t int) *system {
 // If it's a R1CS returns R1CSSolution
 // If it's a SparseR1CS returns SparseR1CSSolution
 func (cs *system) Solve(witness witness.Witness, opts ...csolver.Option) (any, error) {
+	v := witness.Vector().(fr.Vector)
+	return cs.solve(func() (*solver, error) {
+		return newSolver(cs, v, opts...)
+	})
+}
+
+// SolveFrom solves the constraint system with a binary witness read from r. The witness is
+// decoded incrementally into the solver values (see witness.Decoder), it is never
+// materialized. The solution is returned as by Solve; the public values of the witness are
+// the values of the public wires, after the constant one wire.
+func (cs *system) SolveFrom(r io.Reader, opts ...csolver.Option) (any, error) {
+	nbPublic, nbSecret := cs.WitnessSize()
+	dec, err := witness.NewDecoder(r, nbPublic+nbSecret)
+	if err != nil {
+		return nil, err
+	}
+	return cs.solve(func() (*solver, error) {
+		return newSolverFrom(cs, dec, opts...)
+	})
+}
+
+func (cs *system) solve(init func() (*solver, error)) (any, error) {
 	log := logger.Logger().With().Int("nbConstraints", cs.GetNbConstraints()).Logger()
 	start := time.Now()
 
-	v := witness.Vector().(fr.Vector)
-
 	// init the solver
-	solver, err := newSolver(cs, v, opts...)
+	solver, err := init()
 	if err != nil {
 		log.Err(err).Send()
 		return nil, err
@@ -69,6 +89,9 @@ func (cs *system) Solve(witness witness.Witness, opts ...csolver.Option) (any, e
 	// run it.
 	if err := solver.run(); err != nil {
 		log.Err(err).Send()
+		if solver.zeroize {
+			solver.destroy()
+		}
 		return nil, err
 	}
 
@@ -118,35 +141,6 @@ func (cs *system) CurveID() ecc.ID {
 	return ecc.BN254
 }
 
-// WriteTo encodes R1CS into provided io.Writer using gob
-func (cs *system) WriteTo(w io.Writer) (int64, error) {
-	_w := WriterCounter{W: w} // wraps writer to count the bytes written
-
-	// encode our object
-	encoder := gob.NewEncoder(&_w)
-
-	return _w.N, encoder.Encode(cs)
-}
-
-// ReadFrom attempts to decode R1CS from io.Reader using gob
-func (cs *system) ReadFrom(r io.Reader) (int64, error) {
-	_r := ReaderCounter{R: r} // wraps reader to count the bytes written
-	decoder := gob.NewDecoder(&_r)
-
-	// initialize coeff table
-	cs.CoeffTable = newCoeffTable(0)
-
-	if err := decoder.Decode(cs); err != nil {
-		return _r.N, err
-	}
-
-	if err := cs.CheckSerializationHeader(); err != nil {
-		return _r.N, err
-	}
-
-	return _r.N, nil
-}
-
 func (cs *system) GetCoefficient(i int) (r Element) {
 	copy(r[:], cs.Coefficients[i][:])
 	return
@@ -159,6 +153,16 @@ type R1CSSolution struct {
 	A, B, C fr.Vector
 }
 
+// Destroy overwrites the solution vectors with zeros and releases them. The solution holds
+// the secret values of the witness and every value derived from them.
+func (t *R1CSSolution) Destroy() {
+	utils.Zeroize(t.W)
+	utils.Zeroize(t.A)
+	utils.Zeroize(t.B)
+	utils.Zeroize(t.C)
+	t.W, t.A, t.B, t.C = nil, nil, nil, nil
+}
+
 func (t *R1CSSolution) WriteTo(w io.Writer) (int64, error) {
 	n, err := t.W.WriteTo(w)
 	if err != nil {
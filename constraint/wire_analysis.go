@@ -0,0 +1,321 @@
+package cs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// WireReport is the result of the static analysis of the wires and constraints of a
+// constraint system. It is meant to be serialized (JSON) and reviewed, or used to gate
+// a circuit release.
+type WireReport struct {
+	// UnconstrainedPublic and UnconstrainedSecret list the inputs which don't appear
+	// (with a non-zero coefficient) in any constraint, trivial constraints excepted. Inputs
+	// only used as hint inputs are unconstrained.
+	UnconstrainedPublic []WireInfo `json:"unconstrainedPublic"`
+	UnconstrainedSecret []WireInfo `json:"unconstrainedSecret"`
+
+	// UnconstrainedHintOutputs list the wires solved by a hint, or by a custom blueprint
+	// (BlueprintWires), which don't appear (with a non-zero coefficient) in any constraint,
+	// trivial constraints excepted.
+	UnconstrainedHintOutputs []HintOutputInfo `json:"unconstrainedHintOutputs"`
+
+	// UnsatisfiableConstraints list the constraints which reference only the constant one
+	// wire and don't hold: no witness satisfies the system.
+	UnsatisfiableConstraints []ConstraintInfo `json:"unsatisfiableConstraints"`
+
+	// OpaqueInstructions list the instructions whose blueprint doesn't expose its wires
+	// (neither BlueprintR1C, BlueprintHint nor BlueprintWires). The wires they read or
+	// solve can't be analyzed, and may be reported as unconstrained or unused.
+	OpaqueInstructions []int `json:"opaqueInstructions"`
+
+	// UnusedWires list the internal wires which are never read: they only appear in the
+	// instruction solving them. Hint outputs are reported in UnconstrainedHintOutputs instead.
+	UnusedWires []WireInfo `json:"unusedWires"`
+
+	// TrivialConstraints list the constraints that don't constrain any wire.
+	TrivialConstraints []ConstraintInfo `json:"trivialConstraints"`
+}
+
+// WireInfo identifies a wire in a WireReport.
+type WireInfo struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// HintOutputInfo identifies a hint output wire in a WireReport.
+type HintOutputInfo struct {
+	WireInfo
+	Instruction int    `json:"instruction"`         // id of the hint instruction
+	Hint        string `json:"hint"`                // hint name, as registered in MHintsDependencies
+	Blueprint   string `json:"blueprint,omitempty"` // blueprint name, for the outputs of custom blueprints
+}
+
+// ConstraintInfo identifies a constraint in a WireReport.
+type ConstraintInfo struct {
+	Instruction int    `json:"instruction"`
+	Constraint  int    `json:"constraint"`
+	Reason      string `json:"reason"`
+}
+
+// reasons for a constraint to be reported as trivial
+const (
+	TrivialZero     = "zero"     // L or R is zero and O is zero, always satisfied
+	TrivialConstant = "constant" // references only the constant one wire, and holds
+)
+
+// HasErrors returns true if the report contains unconstrained inputs or hint outputs,
+// unsatisfiable constraints or opaque instructions. Unused wires and trivial constraints
+// are considered warnings.
+func (r *WireReport) HasErrors() bool {
+	return len(r.UnconstrainedPublic)+len(r.UnconstrainedSecret)+len(r.UnconstrainedHintOutputs)+
+		len(r.UnsatisfiableConstraints)+len(r.OpaqueInstructions) != 0
+}
+
+// HasWarnings returns true if the report contains unused wires or trivial constraints.
+func (r *WireReport) HasWarnings() bool {
+	return len(r.UnusedWires)+len(r.TrivialConstraints) != 0
+}
+
+// AnalyzeWires walks all the instructions of the system and reports unconstrained inputs,
+// unconstrained hint outputs, unused wires, and constraints which are trivially satisfied
+// or unsatisfiable.
+func (system *system) AnalyzeWires() *WireReport {
+	nbPublic, nbSecret := system.GetNbPublicVariables(), system.GetNbSecretVariables()
+	nbWires := nbPublic + nbSecret + system.GetNbInternalVariables()
+
+	constrained := make([]bool, nbWires)
+	nbUses := make([]uint32, nbWires)    // number of instructions referencing the wire
+	lastUse := make([]int, nbWires)      // last instruction referencing the wire, to count it once
+	hintOutput := make([]int32, nbWires) // instruction id + 1 of the hint or custom blueprint solving the wire, if any
+	for i := range lastUse {
+		lastUse[i] = -1
+	}
+
+	report := &WireReport{}
+
+	markConstrained := func(l LinearExpression) {
+		for _, t := range l {
+			if t.CoeffID() != CoeffIdZero {
+				constrained[t.WireID()] = true
+			}
+		}
+	}
+
+	var (
+		r1c R1C
+		hm  HintMapping
+	)
+	for iID, inst := range system.Instructions {
+		blueprint := system.Blueprints[inst.BlueprintID]
+		calldata := system.GetCallData(inst)
+
+		var it func() int
+		switch bc := blueprint.(type) {
+		case BlueprintR1C:
+			bc.DecompressR1C(&r1c, calldata)
+			// a trivial constraint holds whatever the values of its wires: it doesn't
+			// constrain them.
+			reason := r1c.trivialReason()
+			if reason == "" {
+				markConstrained(r1c.L)
+				markConstrained(r1c.R)
+				markConstrained(r1c.O)
+			} else {
+				info := ConstraintInfo{
+					Instruction: iID,
+					Constraint:  int(inst.ConstraintOffset),
+					Reason:      reason,
+				}
+				if reason == TrivialConstant && !system.constantHolds(&r1c) {
+					report.UnsatisfiableConstraints = append(report.UnsatisfiableConstraints, info)
+				} else {
+					report.TrivialConstraints = append(report.TrivialConstraints, info)
+				}
+			}
+			it = r1c.WireIterator()
+		case BlueprintHint:
+			bc.DecompressHint(&hm, calldata)
+			for wID := hm.OutputRange.Start; wID < hm.OutputRange.End; wID++ {
+				hintOutput[wID] = int32(iID + 1)
+			}
+			it = hm.WireIterator()
+		case BlueprintWires:
+			out := bc.OutputWires(calldata)
+			for wID := out(); wID != -1; wID = out() {
+				hintOutput[wID] = int32(iID + 1)
+			}
+			it = chainWires(bc.InputWires(calldata), bc.OutputWires(calldata))
+		default:
+			report.OpaqueInstructions = append(report.OpaqueInstructions, iID)
+			continue
+		}
+
+		for wID := it(); wID != -1; wID = it() {
+			if lastUse[wID] != iID {
+				lastUse[wID] = iID
+				nbUses[wID]++
+			}
+		}
+	}
+
+	// the one wire is a constant, not an input
+	firstInput := 0
+	if system.Type == ConstrainSystemTypeR1CS {
+		firstInput = 1
+	}
+	for wID := firstInput; wID < nbPublic; wID++ {
+		if !constrained[wID] {
+			report.UnconstrainedPublic = append(report.UnconstrainedPublic, system.wireInfo(wID))
+		}
+	}
+	for wID := nbPublic; wID < nbPublic+nbSecret; wID++ {
+		if !constrained[wID] {
+			report.UnconstrainedSecret = append(report.UnconstrainedSecret, system.wireInfo(wID))
+		}
+	}
+
+	for wID := nbPublic + nbSecret; wID < nbWires; wID++ {
+		if hintOutput[wID] != 0 {
+			if !constrained[wID] {
+				iID := int(hintOutput[wID] - 1)
+				info := HintOutputInfo{
+					WireInfo:    system.wireInfo(wID),
+					Instruction: iID,
+				}
+				inst := system.Instructions[iID]
+				if bc, ok := system.Blueprints[inst.BlueprintID].(BlueprintHint); ok {
+					bc.DecompressHint(&hm, system.GetCallData(inst))
+					info.Hint = system.MHintsDependencies[hm.HintID]
+				} else {
+					info.Blueprint = BlueprintName(system.Blueprints[inst.BlueprintID])
+				}
+				report.UnconstrainedHintOutputs = append(report.UnconstrainedHintOutputs, info)
+			}
+			continue
+		}
+		if nbUses[wID] <= 1 {
+			report.UnusedWires = append(report.UnusedWires, system.wireInfo(wID))
+		}
+	}
+
+	return report
+}
+
+// CheckUnconstrainedWires returns an error listing the inputs and hint outputs which
+// don't appear in any constraint, the unsatisfiable constraints and the instructions which
+// can't be analyzed. See AnalyzeWires for a detailed report.
+func (system *system) CheckUnconstrainedWires() error {
+	report := system.AnalyzeWires()
+	if !report.HasErrors() {
+		return nil
+	}
+
+	var sbb strings.Builder
+	writeWires := func(title string, wires []WireInfo) {
+		if len(wires) == 0 {
+			return
+		}
+		sbb.WriteString(strconv.Itoa(len(wires)))
+		sbb.WriteString(title)
+		sbb.WriteByte('\n')
+		for _, w := range wires {
+			sbb.WriteString(w.Name)
+			sbb.WriteByte('\n')
+		}
+	}
+	writeWires(" unconstrained public input(s):", report.UnconstrainedPublic)
+	writeWires(" unconstrained secret input(s):", report.UnconstrainedSecret)
+	if n := len(report.UnconstrainedHintOutputs); n != 0 {
+		sbb.WriteString(strconv.Itoa(n))
+		sbb.WriteString(" unconstrained hint output(s):")
+		sbb.WriteByte('\n')
+		for _, h := range report.UnconstrainedHintOutputs {
+			sbb.WriteString(h.Name)
+			sbb.WriteString(" (")
+			if h.Hint != "" {
+				sbb.WriteString(h.Hint)
+			} else {
+				sbb.WriteString(h.Blueprint)
+			}
+			sbb.WriteString(", instruction #")
+			sbb.WriteString(strconv.Itoa(h.Instruction))
+			sbb.WriteString(")\n")
+		}
+	}
+	if n := len(report.UnsatisfiableConstraints); n != 0 {
+		sbb.WriteString(strconv.Itoa(n))
+		sbb.WriteString(" unsatisfiable constraint(s):\n")
+		for _, c := range report.UnsatisfiableConstraints {
+			sbb.WriteString("constraint #")
+			sbb.WriteString(strconv.Itoa(c.Constraint))
+			sbb.WriteString(" (instruction #")
+			sbb.WriteString(strconv.Itoa(c.Instruction))
+			sbb.WriteString(")\n")
+		}
+	}
+	if n := len(report.OpaqueInstructions); n != 0 {
+		sbb.WriteString(strconv.Itoa(n))
+		sbb.WriteString(" instruction(s) with a blueprint not exposing its wires:\n")
+		for _, iID := range report.OpaqueInstructions {
+			sbb.WriteString("instruction #")
+			sbb.WriteString(strconv.Itoa(iID))
+			sbb.WriteByte('\n')
+		}
+	}
+	return errors.New(sbb.String())
+}
+
+func (system *System) wireInfo(wID int) WireInfo {
+	return WireInfo{ID: wID, Name: system.VariableToString(wID)}
+}
+
+// trivialReason returns a non empty reason if the constraint doesn't constrain any wire.
+func (r1c *R1C) trivialReason() string {
+	isZero := func(l LinearExpression) bool {
+		for _, t := range l {
+			if t.CoeffID() != CoeffIdZero {
+				return false
+			}
+		}
+		return true
+	}
+	if (isZero(r1c.L) || isZero(r1c.R)) && isZero(r1c.O) {
+		return TrivialZero
+	}
+	it := r1c.WireIterator()
+	for wID := it(); wID != -1; wID = it() {
+		if wID != 0 {
+			return ""
+		}
+	}
+	return TrivialConstant
+}
+
+// constantHolds returns true if the constraint, which references only the constant one
+// wire, is satisfied.
+func (cs *system) constantHolds(r1c *R1C) bool {
+	eval := func(l LinearExpression) fr.Element {
+		var res fr.Element
+		for _, t := range l {
+			res.Add(&res, &cs.Coefficients[t.CoeffID()])
+		}
+		return res
+	}
+	l, r, o := eval(r1c.L), eval(r1c.R), eval(r1c.O)
+	l.Mul(&l, &r)
+	return l.Equal(&o)
+}
+
+// chainWires returns an iterator over the wires of a, then the wires of b.
+func chainWires(a, b func() int) func() int {
+	return func() int {
+		if wID := a(); wID != -1 {
+			return wID
+		}
+		return b()
+	}
+}
@@ -0,0 +1,69 @@
+package cs
+
+import "testing"
+
+// trivialZero returns the constraint w ⋅ 0 == 0, which holds for any value of w.
+func trivialZero(wID int) R1C {
+	return R1C{
+		L: LinearExpression{{CID: CoeffIdOne, VID: uint32(wID)}},
+		R: LinearExpression{{CID: CoeffIdZero, VID: 0}},
+		O: LinearExpression{{CID: CoeffIdZero, VID: 0}},
+	}
+}
+
+func TestAnalyzeWiresTrivialSecret(t *testing.T) {
+	r := NewR1CS(4)
+	r.AddPublicVariable("1")
+	y := r.AddPublicVariable("Y")
+	x := r.AddSecretVariable("X")
+	g := r.AddBlueprint(&BlueprintGenericR1C{})
+	one := Term{CID: CoeffIdOne, VID: 0}
+
+	// Y ⋅ 1 == Y constrains Y, X only appears in X ⋅ 0 == 0
+	r.AddR1C(R1C{L: LinearExpression{{CID: CoeffIdOne, VID: uint32(y)}}, R: LinearExpression{one}, O: LinearExpression{{CID: CoeffIdOne, VID: uint32(y)}}}, g)
+	r.AddR1C(trivialZero(x), g)
+
+	report := r.AnalyzeWires()
+	if len(report.UnconstrainedSecret) != 1 || report.UnconstrainedSecret[0].ID != x {
+		t.Fatalf("expected X to be unconstrained, got %+v", report.UnconstrainedSecret)
+	}
+	if len(report.UnconstrainedPublic) != 0 {
+		t.Fatalf("expected Y to be constrained, got %+v", report.UnconstrainedPublic)
+	}
+	if len(report.TrivialConstraints) != 1 || report.TrivialConstraints[0].Reason != TrivialZero {
+		t.Fatalf("expected a trivial constraint, got %+v", report.TrivialConstraints)
+	}
+	if !report.HasErrors() {
+		t.Fatal("expected errors")
+	}
+}
+
+func TestAnalyzeWiresTrivialHintOutput(t *testing.T) {
+	r := NewR1CS(4)
+	r.AddPublicVariable("1")
+	x := r.AddSecretVariable("X")
+	g := r.AddBlueprint(&BlueprintGenericR1C{})
+	one := Term{CID: CoeffIdOne, VID: 0}
+
+	outputs, err := r.AddHint("wire_analysis_test", []LinearExpression{{{CID: CoeffIdOne, VID: uint32(x)}}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// X == outputs[0] constrains both, outputs[1] only appears in outputs[1] ⋅ 0 == 0
+	r.AddR1C(R1C{L: LinearExpression{{CID: CoeffIdOne, VID: uint32(outputs[0])}}, R: LinearExpression{one}, O: LinearExpression{{CID: CoeffIdOne, VID: uint32(x)}}}, g)
+	r.AddR1C(trivialZero(outputs[1]), g)
+
+	report := r.AnalyzeWires()
+	if len(report.UnconstrainedSecret) != 0 {
+		t.Fatalf("expected X to be constrained, got %+v", report.UnconstrainedSecret)
+	}
+	if len(report.UnconstrainedHintOutputs) != 1 || report.UnconstrainedHintOutputs[0].ID != outputs[1] {
+		t.Fatalf("expected the second hint output to be unconstrained, got %+v", report.UnconstrainedHintOutputs)
+	}
+	if h := report.UnconstrainedHintOutputs[0].Hint; h != "wire_analysis_test" {
+		t.Fatalf("expected hint wire_analysis_test, got %q", h)
+	}
+	if !report.HasErrors() {
+		t.Fatal("expected errors")
+	}
+}
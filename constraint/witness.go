@@ -0,0 +1,129 @@
+package cs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/gnark-tiny-prover-g16/witness"
+)
+
+// WitnessFromJSON builds a full witness from a JSON object of values keyed by the names of
+// the public and secret variables of the system. See witness.FromJSON for the accepted
+// values and naming rules.
+func (cs *system) WitnessFromJSON(data []byte) (witness.Witness, error) {
+	return witness.FromJSON(data, cs.publicInputs(), cs.Secret)
+}
+
+// publicInputs returns the names of the public variables of the witness; in a R1CS, the
+// constant one wire is not part of the witness.
+func (cs *system) publicInputs() []string {
+	if cs.Type == ConstrainSystemTypeR1CS && len(cs.Public) != 0 {
+		return cs.Public[1:]
+	}
+	return cs.Public
+}
+
+// WitnessSize returns the number of public and secret values of a full witness of the
+// system; it implements witness.ConstraintSystem.
+func (cs *system) WitnessSize() (nbPublic, nbSecret int) {
+	return len(cs.publicInputs()), len(cs.Secret)
+}
+
+// PublicInput is a named public value of a witness, with its usual renderings.
+type PublicInput struct {
+	Name    string     `json:"name"`
+	Value   fr.Element `json:"-"`
+	Decimal string     `json:"decimal"`
+	Hex     string     `json:"hex"`   // 0x prefixed
+	Bytes   []byte     `json:"bytes"` // 32 bytes, big-endian
+}
+
+// PublicInputs lists the public values of a witness in witness order. It is encoded in
+// JSON as an object mapping the names to their renderings, keeping the witness order.
+type PublicInputs []PublicInput
+
+// Get returns the public input with the given name.
+func (p PublicInputs) Get(name string) (PublicInput, bool) {
+	for _, in := range p {
+		if in.Name == name {
+			return in, true
+		}
+	}
+	return PublicInput{}, false
+}
+
+// Values returns the decimal value of the public inputs, by name, as accepted by
+// PublicWitness.
+func (p PublicInputs) Values() map[string]any {
+	values := make(map[string]any, len(p))
+	for _, in := range p {
+		values[in.Name] = in.Decimal
+	}
+	return values
+}
+
+func (p PublicInputs) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, in := range p {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(in.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(struct {
+			Decimal string `json:"decimal"`
+			Hex     string `json:"hex"`
+			Bytes   []byte `json:"bytes"`
+		}{in.Decimal, in.Hex, in.Bytes})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// PublicInputs pairs the public values of w, a full or public witness, with the names of
+// the public variables of the system; the constant one wire is skipped.
+func (cs *system) PublicInputs(w witness.Witness) (PublicInputs, error) {
+	public, err := w.Public()
+	if err != nil {
+		return nil, err
+	}
+	v, ok := public.Vector().(fr.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unsupported witness vector %T", public.Vector())
+	}
+	names := cs.publicInputs()
+	if len(v) != len(names) {
+		return nil, fmt.Errorf("%w: got %d public values, expected %d", witness.ErrInvalidWitness, len(v), len(names))
+	}
+
+	inputs := make(PublicInputs, len(v))
+	for i := range v {
+		b := v[i].Bytes()
+		inputs[i] = PublicInput{
+			Name:    names[i],
+			Value:   v[i],
+			Decimal: v[i].String(),
+			Hex:     "0x" + v[i].Text(16),
+			Bytes:   b[:],
+		}
+	}
+	return inputs, nil
+}
+
+// PublicWitness builds the public witness of the system from values keyed by the names of
+// its public variables, typically to verify a proof. See witness.FromValues for the
+// accepted values.
+func (cs *system) PublicWitness(values map[string]any) (witness.Witness, error) {
+	return witness.FromValues(values, cs.publicInputs(), nil)
+}
@@ -3,17 +3,21 @@ module github.com/vocdoni/gnark-tiny-prover-g16
 go 1.20
 
 require (
+	github.com/blang/semver/v4 v4.0.0
 	github.com/consensys/gnark v0.7.2-0.20230428185900-e9ff34a9665c
 	github.com/consensys/gnark-crypto v0.11.0
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/rs/zerolog v1.29.0
 )
 
 require (
 	github.com/bits-and-blooms/bitset v1.5.0 // indirect
 	github.com/consensys/bavard v0.1.13 // indirect
+	github.com/google/pprof v0.0.0-20230309165930-d61513b1440d // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/mmcloughlin/addchain v0.4.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	rsc.io/tmplfunc v0.0.3 // indirect
 )
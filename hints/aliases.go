@@ -0,0 +1,228 @@
+package hints
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	solver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// Upstream gnark derives the hint ids from the fully qualified name of the hint function
+// (runtime.FuncForPC), while this package registers the hints under short names. The
+// tables below map the upstream names to the local names, per gnark version; they only list
+// the hints whose upstream implementation is the same as the local one.
+const (
+	upstreamHint     = "github.com/consensys/gnark/backend/hint."
+	upstreamSolver   = "github.com/consensys/gnark/constraint/solver."
+	upstreamBits     = "github.com/consensys/gnark/std/math/bits."
+	upstreamEmulated = "github.com/consensys/gnark/std/math/emulated."
+	upstreamSelector = "github.com/consensys/gnark/std/selector."
+	upstreamRange    = "github.com/consensys/gnark/std/rangecheck."
+)
+
+// upstreamReported maps the gnark module versions of upstreamAliases to the version they
+// report (gnark.Version), which is the one serialized with the constraint systems (see
+// cs.System.GnarkVersion). Several module versions may report the same version.
+var upstreamReported = map[string]string{
+	"v0.8.0":                               "0.8.0",
+	"v0.7.2-0.20230428185900-e9ff34a9665c": "0.8.1-alpha",
+	"v0.9.1":                               "0.10.0-alpha",
+	"v0.10.0":                              "0.10.0-alpha",
+}
+
+var upstreamAliases = map[string]map[string]string{
+	"v0.8.0": {
+		upstreamHint + "InvZero":                "inv_zero",
+		upstreamBits + "IthBit":                 "ith_bit",
+		upstreamBits + "NBits":                  "n_bits",
+		upstreamBits + "nTrits":                 "n_trits",
+		upstreamBits + "nNaf":                   "nnaf",
+		upstreamEmulated + "DivHint":            "div",
+		upstreamEmulated + "QuoHint":            "quo",
+		upstreamEmulated + "InverseHint":        "inverse",
+		upstreamEmulated + "MultiplicationHint": "multiplication",
+		upstreamEmulated + "RemHint":            "rem",
+	},
+	// the version this module depends on, between v0.8.0 and v0.9.0
+	"v0.7.2-0.20230428185900-e9ff34a9665c": {
+		upstreamSolver + "InvZeroHint":          "inv_zero",
+		upstreamBits + "IthBit":                 "ith_bit",
+		upstreamBits + "NBits":                  "n_bits",
+		upstreamBits + "nTrits":                 "n_trits",
+		upstreamBits + "nNaf":                   "nnaf",
+		upstreamEmulated + "DivHint":            "div",
+		upstreamEmulated + "QuoHint":            "quo",
+		upstreamEmulated + "InverseHint":        "inverse",
+		upstreamEmulated + "MultiplicationHint": "multiplication",
+		upstreamEmulated + "RemHint":            "rem",
+		upstreamEmulated + "RightShift":         "right_shift",
+		upstreamSelector + "stepOutput":         "step_output",
+		upstreamSelector + "muxIndicators":      "mux_indicators",
+		upstreamSelector + "mapIndicators":      "map_indicators",
+		upstreamRange + "DecomposeHint":         "decompose",
+	},
+	"v0.9.1": {
+		upstreamSolver + "InvZeroHint":          "inv_zero",
+		upstreamBits + "ithBit":                 "ith_bit",
+		upstreamBits + "nBits":                  "n_bits",
+		upstreamBits + "nTrits":                 "n_trits",
+		upstreamBits + "nNaf":                   "nnaf",
+		upstreamEmulated + "DivHint":            "div",
+		upstreamEmulated + "QuoHint":            "quo",
+		upstreamEmulated + "InverseHint":        "inverse",
+		upstreamEmulated + "MultiplicationHint": "multiplication",
+		upstreamEmulated + "RemHint":            "rem",
+		upstreamEmulated + "RightShift":         "right_shift",
+		upstreamSelector + "stepOutput":         "step_output",
+		upstreamSelector + "muxIndicators":      "mux_indicators",
+		upstreamSelector + "mapIndicators":      "map_indicators",
+		upstreamRange + "DecomposeHint":         "decompose",
+	},
+	// quo, multiplication, rem and right_shift were replaced in v0.10.0
+	"v0.10.0": {
+		upstreamSolver + "InvZeroHint":     "inv_zero",
+		upstreamBits + "ithBit":            "ith_bit",
+		upstreamBits + "nBits":             "n_bits",
+		upstreamBits + "nTrits":            "n_trits",
+		upstreamBits + "nNaf":              "nnaf",
+		upstreamEmulated + "DivHint":       "div",
+		upstreamEmulated + "InverseHint":   "inverse",
+		upstreamSelector + "stepOutput":    "step_output",
+		upstreamSelector + "muxIndicators": "mux_indicators",
+		upstreamSelector + "mapIndicators": "map_indicators",
+		upstreamRange + "DecomposeHint":    "decompose",
+	},
+}
+
+// UpstreamVersions returns the gnark module versions with known hint names, in semver
+// order.
+func UpstreamVersions() []string {
+	versions := make([]string, 0, len(upstreamAliases))
+	for v := range upstreamAliases {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.MustParse(strings.TrimPrefix(versions[i], "v")).LT(semver.MustParse(strings.TrimPrefix(versions[j], "v")))
+	})
+	return versions
+}
+
+// UpstreamAliases returns the upstream hint names of a gnark version, mapped to the local
+// hint names. The version is either a module version or the version reported by gnark, with
+// or without the "v" prefix; see ResolveHint.
+func UpstreamAliases(version string) (map[string]string, bool) {
+	res := make(map[string]string)
+	found := false
+	for _, v := range UpstreamVersions() {
+		if !matchVersion(v, version) {
+			continue
+		}
+		found = true
+		for k, local := range upstreamAliases[v] {
+			res[k] = local
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return res, true
+}
+
+// ResolveHint returns the local implementation of the hint with the given name, which is
+// either a local name or an upstream name of the given gnark version. The returned hint has
+// the id derived from name.
+//
+// The version is a gnark module version (see UpstreamVersions) or the version reported by
+// gnark and stored in the systems it serialized (cs.System.GnarkVersion); the "v" prefix
+// is optional. An empty version matches any known version, the first one in the order of
+// UpstreamVersions which knows the name is used.
+func ResolveHint(name, version string) (solver.Hint, bool) {
+	local := name
+	if alias, ok := lookupAlias(name, version); ok {
+		local = alias
+	}
+	for _, h := range stdHints() {
+		if h.Name == local {
+			h.ID, h.Name = solver.GetHintID(name), name
+			return h, true
+		}
+	}
+	return solver.Hint{}, false
+}
+
+func lookupAlias(name, version string) (string, bool) {
+	for _, v := range UpstreamVersions() {
+		if !matchVersion(v, version) {
+			continue
+		}
+		if local, ok := upstreamAliases[v][name]; ok {
+			return local, true
+		}
+	}
+	return "", false
+}
+
+// matchVersion reports whether version, a module or reported gnark version, designates the
+// module version v of upstreamAliases. An empty version matches any version.
+func matchVersion(v, version string) bool {
+	if version == "" {
+		return true
+	}
+	version = strings.TrimPrefix(version, "v")
+	return version == strings.TrimPrefix(v, "v") || version == upstreamReported[v]
+}
+
+// HintReport is the result of ResolveHintDependencies.
+type HintReport struct {
+	Resolved   map[string]string // dependency name to local hint name
+	Unresolved []string          // sorted names of the dependencies without local implementation
+}
+
+func (r HintReport) String() string {
+	var sbb strings.Builder
+	names := make([]string, 0, len(r.Resolved))
+	for name := range r.Resolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(&sbb, "%d resolved hint(s)\n", len(names))
+	for _, name := range names {
+		fmt.Fprintf(&sbb, "\t%s -> %s\n", name, r.Resolved[name])
+	}
+	fmt.Fprintf(&sbb, "%d unresolved hint(s)\n", len(r.Unresolved))
+	for _, name := range r.Unresolved {
+		fmt.Fprintf(&sbb, "\t%s\n", name)
+	}
+	return sbb.String()
+}
+
+// ResolveHintDependencies resolves the hint dependencies of a constraint system
+// (System.MHintsDependencies) by name, as ResolveHint does. It returns the resolved hints,
+// with the ids of the dependencies, to be registered in the solver registry, and a report
+// of the resolution.
+//
+// Unresolved dependencies are not necessarily an error: the commitment hint is provided by
+// the prover, and custom hints may be registered by the caller.
+func ResolveHintDependencies(dependencies map[solver.HintID]string, version string) ([]solver.Hint, HintReport) {
+	report := HintReport{Resolved: make(map[string]string)}
+	var resolved []solver.Hint
+	for id, name := range dependencies {
+		h, ok := ResolveHint(name, version)
+		if !ok {
+			report.Unresolved = append(report.Unresolved, name)
+			continue
+		}
+		h.ID = id
+		resolved = append(resolved, h)
+		local, _ := lookupAlias(name, version)
+		if local == "" {
+			local = name
+		}
+		report.Resolved[name] = local
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Name < resolved[j].Name })
+	sort.Strings(report.Unresolved)
+	return resolved, report
+}
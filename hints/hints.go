@@ -21,14 +21,28 @@ func RegisterHints() {
 	registerOnce.Do(registerHints)
 }
 
+// NewHintRegistry returns a registry with all gnark/std hints, to be given to the solver
+// with solver.WithHintRegistry. Unlike RegisterHints, it doesn't use the global registry.
+func NewHintRegistry() (*solver.HintRegistry, error) {
+	r := solver.NewHintRegistry()
+	if err := r.Register(stdHints()...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
 func registerHints() {
 	// note that importing these packages may already trigger a call to solver.RegisterHint(...)
-	solver.RegisterHint(solver.NewHint("n_trits", bits.NTrits))
-	solver.RegisterHint(solver.NewHint("nnaf", bits.NNAF))
-	solver.RegisterHint(solver.NewHint("ith_bit", bits.IthBit))
-	solver.RegisterHint(solver.NewHint("n_bits", bits.NBits))
-	solver.RegisterHint(selector.GetHints()...)
-	solver.RegisterHint(emulated.GetHints()...)
-	solver.RegisterHint(solver.NewHint("count", rangecheck.CountHint))
-	solver.RegisterHint(solver.NewHint("decompose", rangecheck.DecomposeHint))
+	solver.RegisterHint(stdHints()...)
+}
+
+func stdHints() []solver.Hint {
+	hints := []solver.Hint{
+		solver.NewHint("inv_zero", solver.InvZeroHint),
+		solver.NewHint("count", rangecheck.CountHint),
+		solver.NewHint("decompose", rangecheck.DecomposeHint),
+	}
+	hints = append(hints, bits.GetHints()...)
+	hints = append(hints, selector.GetHints()...)
+	return append(hints, emulated.GetHints()...)
 }
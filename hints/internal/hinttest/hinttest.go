@@ -0,0 +1,39 @@
+// Package hinttest provides helpers to test the hint implementations.
+package hinttest
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	solver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// CrossCheck runs the big.Int and native implementations of a hint on inputs, and
+// compares their nbOutputs outputs.
+func CrossCheck(t *testing.T, name string, fn solver.HintFn, native solver.NativeHintFn, inputs []fr.Element, nbOutputs int) {
+	t.Helper()
+	bigInputs := make([]*big.Int, len(inputs))
+	for i := range inputs {
+		bigInputs[i] = inputs[i].BigInt(new(big.Int))
+	}
+	bigOutputs := make([]*big.Int, nbOutputs)
+	for i := range bigOutputs {
+		bigOutputs[i] = new(big.Int)
+	}
+	if err := fn(fr.Modulus(), bigInputs, bigOutputs); err != nil {
+		t.Fatalf("%s%v: %s", name, bigInputs, err)
+	}
+
+	outputs := make([]fr.Element, nbOutputs)
+	if err := native(inputs, outputs); err != nil {
+		t.Fatalf("%s%v: native: %s", name, bigInputs, err)
+	}
+	for i := range outputs {
+		var expected fr.Element
+		expected.SetBigInt(bigOutputs[i])
+		if !outputs[i].Equal(&expected) {
+			t.Fatalf("%s%v: output %d is %s, expected %s", name, bigInputs, i, outputs[i].String(), expected.String())
+		}
+	}
+}
@@ -2,8 +2,21 @@ package bits
 
 import (
 	"errors"
+
+	solver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
 )
 
+// GetHints returns all hint functions used in this package. This method is
+// useful for registering all hints in the solver.
+func GetHints() []solver.Hint {
+	return []solver.Hint{
+		solver.NewHint("ith_bit", IthBit).WithNative(ithBitNative),
+		solver.NewHint("n_bits", NBits).WithNative(nBitsNative),
+		solver.NewHint("n_trits", NTrits),
+		solver.NewHint("nnaf", NNAF),
+	}
+}
+
 // Base defines the base for decomposing the scalar into digits.
 type Base uint8
 
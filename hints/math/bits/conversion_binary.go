@@ -3,13 +3,14 @@ package bits
 import (
 	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	solver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
 )
 
 func init() {
 	// register hints
-	solver.RegisterHint(solver.NewHint("ith_bit", IthBit))
-	solver.RegisterHint(solver.NewHint("n_bits", NBits))
+	solver.RegisterHint(solver.NewHint("ith_bit", IthBit).WithNative(ithBitNative))
+	solver.RegisterHint(solver.NewHint("n_bits", NBits).WithNative(nBitsNative))
 }
 
 // IthBit returns the i-tb bit the input. The function expects exactly two
@@ -35,3 +36,29 @@ func NBits(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
 	}
 	return nil
 }
+
+// ithBitNative is the native implementation of IthBit.
+func ithBitNative(inputs []fr.Element, results []fr.Element) error {
+	if !inputs[1].IsUint64() {
+		return nil
+	}
+	results[0].SetUint64(bit(inputs[0].Bits(), inputs[1].Uint64()))
+	return nil
+}
+
+// nBitsNative is the native implementation of NBits.
+func nBitsNative(inputs []fr.Element, results []fr.Element) error {
+	n := inputs[0].Bits()
+	for i := range results {
+		results[i].SetUint64(bit(n, uint64(i)))
+	}
+	return nil
+}
+
+// bit returns the i-th bit of the regular (non-Montgomery) form of an element.
+func bit(words [fr.Limbs]uint64, i uint64) uint64 {
+	if i >= fr.Bits {
+		return 0
+	}
+	return (words[i/64] >> (i % 64)) & 1
+}
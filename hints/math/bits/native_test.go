@@ -0,0 +1,58 @@
+package bits
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints/internal/hinttest"
+)
+
+// edgeValues returns field elements around the word and field boundaries.
+func edgeValues() []fr.Element {
+	q := fr.Modulus()
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(253),
+		big.NewInt(254),
+		big.NewInt(255),
+		big.NewInt(256),
+		new(big.Int).SetUint64(1<<32 + 1),
+		new(big.Int).SetUint64(1<<62 + 7),
+		new(big.Int).SetUint64(1<<64 - 1),
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(3)),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+		new(big.Int).Lsh(big.NewInt(1), 253),
+		new(big.Int).Sub(q, big.NewInt(1)),
+		new(big.Int).Rsh(q, 1),
+	}
+	res := make([]fr.Element, len(values))
+	for i, v := range values {
+		res[i].SetBigInt(v)
+	}
+	return res
+}
+
+func TestNBitsNative(t *testing.T) {
+	for _, v := range edgeValues() {
+		for _, nbOutputs := range []int{1, 8, 64, 65, 254, 256} {
+			hinttest.CrossCheck(t, "NBits", NBits, nBitsNative, []fr.Element{v}, nbOutputs)
+		}
+	}
+}
+
+func TestIthBitNative(t *testing.T) {
+	// the index of IthBit is converted to an int: indices from 2^63 to 2^64 make it panic,
+	// and are not checked.
+	values := edgeValues()
+	for _, v := range values {
+		for _, i := range values {
+			if i.IsUint64() && i.Uint64() >= 1<<63 {
+				continue
+			}
+			hinttest.CrossCheck(t, "IthBit", IthBit, ithBitNative, []fr.Element{v, i}, 1)
+		}
+	}
+}
@@ -15,6 +15,7 @@ package selector
 import (
 	"math/big"
 
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	solver "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
 )
 
@@ -27,9 +28,9 @@ func init() {
 // useful for registering all hints in the solver.
 func GetHints() []solver.Hint {
 	return []solver.Hint{
-		solver.NewHint("step_output", stepOutput),
-		solver.NewHint("mux_indicators", muxIndicators),
-		solver.NewHint("map_indicators", mapIndicators)}
+		solver.NewHint("step_output", stepOutput).WithNative(stepOutputNative),
+		solver.NewHint("mux_indicators", muxIndicators).WithNative(muxIndicatorsNative),
+		solver.NewHint("map_indicators", mapIndicators).WithNative(mapIndicatorsNative)}
 }
 
 // muxIndicators is a hint function used within [Mux] function. It must be
@@ -48,6 +49,16 @@ func muxIndicators(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
 	return nil
 }
 
+// muxIndicatorsNative is the native implementation of muxIndicators.
+func muxIndicatorsNative(inputs []fr.Element, results []fr.Element) error {
+	sel := &inputs[0]
+	if !sel.IsUint64() || sel.Uint64() >= uint64(len(results)) {
+		return nil
+	}
+	results[sel.Uint64()].SetOne()
+	return nil
+}
+
 // mapIndicators is a hint function used within [Map] function. It must be
 // provided to the prover when circuit uses it.
 func mapIndicators(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
@@ -62,3 +73,14 @@ func mapIndicators(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
 	}
 	return nil
 }
+
+// mapIndicatorsNative is the native implementation of mapIndicators.
+func mapIndicatorsNative(inputs []fr.Element, results []fr.Element) error {
+	key := &inputs[len(inputs)-1]
+	for i := range results {
+		if key.Equal(&inputs[i]) {
+			results[i].SetOne()
+		}
+	}
+	return nil
+}
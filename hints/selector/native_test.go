@@ -0,0 +1,63 @@
+package selector
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/vocdoni/gnark-tiny-prover-g16/hints/internal/hinttest"
+)
+
+// edgeValues returns field elements around the output counts, the word and the field
+// boundaries.
+func edgeValues() []fr.Element {
+	q := fr.Modulus()
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(3),
+		big.NewInt(4),
+		big.NewInt(5),
+		big.NewInt(254),
+		new(big.Int).SetUint64(1<<63 - 1),
+		new(big.Int).SetUint64(1 << 63),
+		new(big.Int).SetUint64(1<<64 - 1),
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(2)),
+		new(big.Int).Lsh(big.NewInt(1), 200),
+		new(big.Int).Sub(q, big.NewInt(1)),
+	}
+	res := make([]fr.Element, len(values))
+	for i, v := range values {
+		res[i].SetBigInt(v)
+	}
+	return res
+}
+
+func TestStepOutputNative(t *testing.T) {
+	values := edgeValues()
+	for _, pos := range values {
+		for _, nbOutputs := range []int{1, 4, 5} {
+			hinttest.CrossCheck(t, "stepOutput", stepOutput, stepOutputNative, []fr.Element{pos, values[2], values[len(values)-1]}, nbOutputs)
+		}
+	}
+}
+
+func TestMuxIndicatorsNative(t *testing.T) {
+	for _, sel := range edgeValues() {
+		for _, nbOutputs := range []int{1, 4, 5} {
+			hinttest.CrossCheck(t, "muxIndicators", muxIndicators, muxIndicatorsNative, []fr.Element{sel}, nbOutputs)
+		}
+	}
+}
+
+func TestMapIndicatorsNative(t *testing.T) {
+	values := edgeValues()
+	for _, key := range values {
+		// the keys are the edge values, with duplicates
+		inputs := append(append([]fr.Element{}, values...), values[:4]...)
+		hinttest.CrossCheck(t, "mapIndicators", mapIndicators, mapIndicatorsNative, append(inputs, key), len(inputs))
+		// key absent
+		hinttest.CrossCheck(t, "mapIndicators", mapIndicators, mapIndicatorsNative, append(values[:2:2], key), 2)
+	}
+}
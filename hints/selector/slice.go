@@ -2,6 +2,8 @@ package selector
 
 import (
 	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
 // stepOutput is a hint function used within [StepMask] function. It must be
@@ -19,3 +21,17 @@ func stepOutput(_ *big.Int, inputs, results []*big.Int) error {
 	}
 	return nil
 }
+
+// stepOutputNative is the native implementation of stepOutput.
+func stepOutputNative(inputs, results []fr.Element) error {
+	// as big.Int.Int64, keep the low 64 bits of the step position
+	stepPos := int64(inputs[0].Bits()[0])
+	for i := range results {
+		if int64(i) < stepPos {
+			results[i] = inputs[1]
+		} else {
+			results[i] = inputs[2]
+		}
+	}
+	return nil
+}
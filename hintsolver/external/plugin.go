@@ -0,0 +1,273 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// ErrTimeout is returned when the plugin doesn't answer a request in time.
+var ErrTimeout = errors.New("hint plugin timeout")
+
+const (
+	// DefaultTimeout is the default time the plugin has to answer a request.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultMaxRestarts is the default number of times the plugin is restarted (or
+	// reconnected to) in a row before the calls fail.
+	DefaultMaxRestarts = 3
+)
+
+// Option configures a Plugin.
+type Option func(*Plugin)
+
+// WithTimeout sets the time the plugin has to answer a request, including the time to
+// start the process or connect to the socket. A timeout of 0 disables it. Since timed out
+// requests are retried, a call may take up to (maxRestarts+1)*timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Plugin) {
+		p.timeout = timeout
+	}
+}
+
+// WithMaxRestarts sets the number of times the plugin is restarted in a row after a
+// failure (a crash, a timeout or a protocol error) before the calls fail.
+func WithMaxRestarts(n int) Option {
+	return func(p *Plugin) {
+		p.maxRestarts = n
+	}
+}
+
+// WithStderr sets the writer the standard error of the plugin process is copied to. By
+// default it is discarded.
+func WithStderr(w io.Writer) Option {
+	return func(p *Plugin) {
+		p.stderr = w
+	}
+}
+
+// Plugin is a connection to an out-of-process hint plugin, started as a child process
+// (NewProcess) or listening on a Unix socket (NewUnixSocket). The plugin is started or
+// connected to at the first call.
+//
+// The requests are sent one at a time, so the calls of the solver to the hints of a plugin
+// are serialized. When the plugin fails, the connection is closed (and the process killed)
+// and the request is retried on a new connection, up to the maximal number of restarts.
+// Errors returned by the hint functions of the plugin (*HintError) are not retried.
+//
+// A Plugin is safe for concurrent use.
+type Plugin struct {
+	name        string
+	dial        func() (io.ReadWriteCloser, error)
+	timeout     time.Duration
+	maxRestarts int
+	stderr      io.Writer
+
+	m        sync.Mutex
+	conn     io.ReadWriteCloser
+	restarts int // consecutive restarts
+}
+
+// NewProcess returns a plugin running the command path with the given arguments, and
+// exchanging the frames over its standard input and output.
+func NewProcess(path string, args []string, opts ...Option) *Plugin {
+	p := newPlugin(path, opts)
+	p.dial = func() (io.ReadWriteCloser, error) {
+		return startProcess(path, args, p.stderr)
+	}
+	return p
+}
+
+// NewUnixSocket returns a plugin listening on the Unix socket at path.
+func NewUnixSocket(path string, opts ...Option) *Plugin {
+	p := newPlugin(path, opts)
+	p.dial = func() (io.ReadWriteCloser, error) {
+		return net.DialTimeout("unix", path, p.timeout)
+	}
+	return p
+}
+
+func newPlugin(name string, opts []Option) *Plugin {
+	p := &Plugin{
+		name:        name,
+		timeout:     DefaultTimeout,
+		maxRestarts: DefaultMaxRestarts,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// HintFn returns a hint function forwarding its calls to the hint with the given name of
+// the plugin.
+func (p *Plugin) HintFn(name string) hintsolver.HintFn {
+	return func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		return p.Call(name, q, inputs, outputs)
+	}
+}
+
+// Hint returns the hint with the given name, implemented by the plugin. It can be
+// registered in a HintRegistry or given to the solver with WithHints.
+func (p *Plugin) Hint(name string) hintsolver.Hint {
+	return hintsolver.NewHint(name, p.HintFn(name))
+}
+
+// Hints returns the hints with the given names, implemented by the plugin.
+func (p *Plugin) Hints(names ...string) []hintsolver.Hint {
+	hints := make([]hintsolver.Hint, len(names))
+	for i, name := range names {
+		hints[i] = p.Hint(name)
+	}
+	return hints
+}
+
+// Call calls the hint with the given name of the plugin.
+func (p *Plugin) Call(name string, q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	req, err := encodeRequest(name, q, inputs, len(outputs))
+	if err != nil {
+		return fmt.Errorf("hint plugin %s: %s: %w", p.name, name, err)
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	for {
+		res, err := p.exchange(req)
+		if err == nil {
+			err = decodeResponse(res, q, outputs)
+		}
+		var hintErr *HintError
+		if err == nil || errors.As(err, &hintErr) {
+			p.restarts = 0
+			if err != nil {
+				return fmt.Errorf("hint plugin %s: %s: %w", p.name, name, err)
+			}
+			return nil
+		}
+
+		// the connection is in an unknown state: drop it, and retry on a new one
+		p.drop()
+		if p.restarts >= p.maxRestarts {
+			return fmt.Errorf("hint plugin %s: %s: %w", p.name, name, err)
+		}
+		p.restarts++
+	}
+}
+
+// exchange sends the request and reads the response, connecting to the plugin first if
+// needed.
+func (p *Plugin) exchange(req []byte) ([]byte, error) {
+	type result struct {
+		payload []byte
+		err     error
+	}
+	done := make(chan result, 1)
+	conn := p.conn
+	go func() {
+		if conn == nil {
+			c, err := p.dial()
+			if err != nil {
+				done <- result{err: err}
+				return
+			}
+			conn = c
+		}
+		if err := writeFrame(conn, req); err != nil {
+			done <- result{err: err}
+			return
+		}
+		payload, err := readFrame(conn)
+		done <- result{payload, err}
+	}()
+
+	var timeout <-chan time.Time
+	if p.timeout > 0 {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case r := <-done:
+		p.conn = conn
+		return r.payload, r.err
+	case <-timeout:
+		// closing the connection unblocks the goroutine; a connection it may still open is
+		// closed once it returns.
+		if p.conn != nil {
+			p.drop()
+		} else {
+			go func() {
+				<-done
+				if conn != nil {
+					conn.Close()
+				}
+			}()
+		}
+		return nil, ErrTimeout
+	}
+}
+
+// drop closes the connection, killing the plugin process.
+func (p *Plugin) drop() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// Close closes the connection to the plugin, killing the plugin process. The plugin is
+// restarted at the next call.
+func (p *Plugin) Close() error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.drop()
+	p.restarts = 0
+	return nil
+}
+
+// process is a plugin process, read from its standard output and written to its standard
+// input.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func startProcess(path string, args []string, stderr io.Writer) (*process, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &process{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+func (p *process) Read(b []byte) (int, error) {
+	return p.stdout.Read(b)
+}
+
+func (p *process) Write(b []byte) (int, error) {
+	return p.stdin.Write(b)
+}
+
+// Close kills the process and waits for it to exit.
+func (p *process) Close() error {
+	p.stdin.Close()
+	p.cmd.Process.Kill() // #nosec G104 -- the process may have exited
+	return p.cmd.Wait()
+}
@@ -0,0 +1,174 @@
+package external
+
+import (
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// the test binary serves testHints on its standard input and output when this variable is
+// set, to be run as a plugin process.
+const pluginEnv = "EXTERNAL_TEST_PLUGIN"
+
+var testHints = map[string]hintsolver.HintFn{
+	"square": func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		for i := range inputs {
+			outputs[i].Mul(inputs[i], inputs[i]).Mod(outputs[i], q)
+		}
+		return nil
+	},
+	"sleep": func(q *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		time.Sleep(time.Duration(inputs[0].Uint64()) * time.Millisecond)
+		return nil
+	},
+	"fail": func(*big.Int, []*big.Int, []*big.Int) error {
+		return errors.New("failing as requested")
+	},
+	"exit": func(*big.Int, []*big.Int, []*big.Int) error {
+		os.Exit(3)
+		return nil
+	},
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginEnv) != "" {
+		if err := Serve(os.Stdin, os.Stdout, testHints); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// pipePlugin returns a plugin served in process over net.Pipe, and the number of
+// connections it made.
+func pipePlugin(opts ...Option) (*Plugin, *int32) {
+	var nbDials int32
+	p := newPlugin("pipe", opts)
+	p.dial = func() (io.ReadWriteCloser, error) {
+		atomic.AddInt32(&nbDials, 1)
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			Serve(server, server, testHints) // #nosec G104 -- the client sees the closed pipe
+		}()
+		return client, nil
+	}
+	return p, &nbDials
+}
+
+var testQ = big.NewInt(1000003)
+
+func square(t *testing.T, p *Plugin, x int64) {
+	t.Helper()
+	outputs := []*big.Int{new(big.Int)}
+	if err := p.Call("square", testQ, []*big.Int{big.NewInt(x)}, outputs); err != nil {
+		t.Fatal(err)
+	}
+	if expected := new(big.Int).Mod(big.NewInt(x*x), testQ); outputs[0].Cmp(expected) != 0 {
+		t.Fatalf("square(%d) = %s, expected %s", x, outputs[0], expected)
+	}
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	p, nbDials := pipePlugin()
+	defer p.Close()
+
+	square(t, p, 7)
+	square(t, p, 1000)
+
+	// through the solver hint function
+	outputs := []*big.Int{new(big.Int), new(big.Int)}
+	if err := p.HintFn("square")(testQ, []*big.Int{big.NewInt(2), big.NewInt(3)}, outputs); err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].Int64() != 4 || outputs[1].Int64() != 9 {
+		t.Fatalf("got %v", outputs)
+	}
+	if n := atomic.LoadInt32(nbDials); n != 1 {
+		t.Fatalf("expected a single connection, got %d", n)
+	}
+}
+
+func TestPluginHintError(t *testing.T) {
+	p, nbDials := pipePlugin()
+	defer p.Close()
+
+	err := p.Call("fail", testQ, nil, nil)
+	var hintErr *HintError
+	if !errors.As(err, &hintErr) || hintErr.Message != "failing as requested" {
+		t.Fatalf("expected the hint error, got %v", err)
+	}
+
+	// the plugin keeps serving on the same connection
+	square(t, p, 5)
+	if n := atomic.LoadInt32(nbDials); n != 1 {
+		t.Fatalf("expected a single connection, got %d", n)
+	}
+}
+
+func TestPluginUnknownHint(t *testing.T) {
+	p, _ := pipePlugin()
+	defer p.Close()
+
+	err := p.Call("nope", testQ, nil, []*big.Int{new(big.Int)})
+	var hintErr *HintError
+	if !errors.As(err, &hintErr) || !strings.Contains(hintErr.Message, "unknown hint nope") {
+		t.Fatalf("expected an unknown hint error, got %v", err)
+	}
+	square(t, p, 5)
+}
+
+func TestPluginTimeout(t *testing.T) {
+	p, nbDials := pipePlugin(WithTimeout(50*time.Millisecond), WithMaxRestarts(1))
+	defer p.Close()
+
+	start := time.Now()
+	err := p.Call("sleep", testQ, []*big.Int{big.NewInt(500)}, nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("the call took %s", elapsed)
+	}
+	// timed out once, and once more after the restart
+	if n := atomic.LoadInt32(nbDials); n != 2 {
+		t.Fatalf("expected 2 connections, got %d", n)
+	}
+
+	// the next call is served by a new connection
+	square(t, p, 3)
+	if n := atomic.LoadInt32(nbDials); n != 3 {
+		t.Fatalf("expected 3 connections, got %d", n)
+	}
+}
+
+func TestPluginProcessRestart(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Setenv(pluginEnv, "1")
+	p := NewProcess(exe, nil, WithTimeout(10*time.Second), WithMaxRestarts(0))
+	defer p.Close()
+
+	square(t, p, 11)
+
+	// the process exits while answering: the call fails, without a HintError
+	err = p.Call("exit", testQ, nil, nil)
+	var hintErr *HintError
+	if err == nil || errors.As(err, &hintErr) {
+		t.Fatalf("expected a connection error, got %v", err)
+	}
+
+	// the next call restarts the process
+	square(t, p, 12)
+}
@@ -0,0 +1,218 @@
+// Package external forwards hint functions to a process outside of the prover, for hints
+// implemented in other languages or with libraries that can't be linked into the prover.
+//
+// # Protocol
+//
+// The prover and the plugin exchange frames over a stream (the stdin and stdout of the
+// plugin process, or a Unix socket). A frame is a big-endian uint32 length followed by
+// that many bytes; all the integers are big-endian. The prover sends one request and waits
+// for its response before sending the next one:
+//
+//	Request   ->  [uint8(version) | uint16(len(name)) | name | uint16(len(q)) | q | uint32(nbInputs) | uint32(nbOutputs) | inputs]
+//	Response  ->  [uint8(status) | outputs]  if status == 0
+//	              [uint8(status) | message]  otherwise
+//
+// name is the name of the hint, q the field modulus, and the inputs and outputs are
+// integers in [0, q), each encoded on len(q) bytes. On error, the plugin answers with a
+// non-zero status and an UTF-8 error message, and keeps serving.
+package external
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ProtocolVersion is the version of the protocol, sent in every request.
+const ProtocolVersion = 1
+
+const (
+	statusOK    = 0
+	statusError = 1
+
+	// maxFrameSize bounds the size of the frames read, such that a corrupted length can't
+	// trigger a large allocation.
+	maxFrameSize = 1 << 28
+)
+
+// ErrProtocol is returned when a peer sends a malformed frame.
+var ErrProtocol = errors.New("hint plugin protocol error")
+
+// request is a decoded request frame.
+type request struct {
+	name      string
+	q         *big.Int
+	inputs    []*big.Int
+	nbOutputs int
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("%w: frame of %d bytes", ErrProtocol, n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
+
+// encodeRequest returns the payload of a request.
+func encodeRequest(name string, q *big.Int, inputs []*big.Int, nbOutputs int) ([]byte, error) {
+	bq := q.Bytes()
+	if len(name) > 0xffff || len(bq) > 0xffff {
+		return nil, fmt.Errorf("%w: name or modulus too long", ErrProtocol)
+	}
+	size := len(bq)
+	payload := make([]byte, 0, 1+2+len(name)+2+size+8+len(inputs)*size)
+	payload = append(payload, ProtocolVersion)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(name)))
+	payload = append(payload, name...)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(size))
+	payload = append(payload, bq...)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(len(inputs)))
+	payload = binary.BigEndian.AppendUint32(payload, uint32(nbOutputs))
+	for i, in := range inputs {
+		if in.Sign() < 0 || in.Cmp(q) >= 0 {
+			return nil, fmt.Errorf("input %d not in [0, q)", i)
+		}
+		payload = append(payload, make([]byte, size)...)
+		in.FillBytes(payload[len(payload)-size:])
+	}
+	return payload, nil
+}
+
+// decodeRequest decodes the payload of a request.
+func decodeRequest(payload []byte) (*request, error) {
+	d := decoder{b: payload}
+	if v := d.uint8(); d.err == nil && v != ProtocolVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrProtocol, v)
+	}
+	var req request
+	req.name = string(d.bytes(int(d.uint16())))
+	size := int(d.uint16())
+	req.q = new(big.Int).SetBytes(d.bytes(size))
+	nbInputs := d.uint32()
+	req.nbOutputs = int(d.uint32())
+	if d.err != nil {
+		return nil, d.err
+	}
+	if size == 0 || uint64(nbInputs)*uint64(size) != uint64(len(d.b)) {
+		return nil, fmt.Errorf("%w: %d inputs of %d bytes, got %d bytes", ErrProtocol, nbInputs, size, len(d.b))
+	}
+	if uint64(req.nbOutputs)*uint64(size) > maxFrameSize {
+		return nil, fmt.Errorf("%w: %d outputs", ErrProtocol, req.nbOutputs)
+	}
+	req.inputs = make([]*big.Int, nbInputs)
+	for i := range req.inputs {
+		req.inputs[i] = new(big.Int).SetBytes(d.bytes(size))
+	}
+	return &req, nil
+}
+
+// encodeResponse returns the payload of a response with the outputs of a hint, or its
+// error.
+func encodeResponse(q *big.Int, outputs []*big.Int, err error) []byte {
+	if err != nil {
+		return append([]byte{statusError}, err.Error()...)
+	}
+	size := len(q.Bytes())
+	payload := make([]byte, 1+len(outputs)*size)
+	payload[0] = statusOK
+	for i, out := range outputs {
+		if out.Sign() < 0 || out.Cmp(q) >= 0 {
+			return append([]byte{statusError}, fmt.Sprintf("output %d not in [0, q)", i)...)
+		}
+		out.FillBytes(payload[1+i*size : 1+(i+1)*size])
+	}
+	return payload
+}
+
+// decodeResponse sets the outputs from the payload of a response, or returns the error
+// sent by the plugin.
+func decodeResponse(payload []byte, q *big.Int, outputs []*big.Int) error {
+	if len(payload) == 0 {
+		return fmt.Errorf("%w: empty response", ErrProtocol)
+	}
+	if payload[0] != statusOK {
+		return &HintError{Message: string(payload[1:])}
+	}
+	size := len(q.Bytes())
+	if len(payload)-1 != len(outputs)*size {
+		return fmt.Errorf("%w: expected %d outputs of %d bytes, got %d bytes", ErrProtocol, len(outputs), size, len(payload)-1)
+	}
+	for i := range outputs {
+		outputs[i].SetBytes(payload[1+i*size : 1+(i+1)*size])
+		if outputs[i].Cmp(q) >= 0 {
+			return fmt.Errorf("%w: output %d not in [0, q)", ErrProtocol, i)
+		}
+	}
+	return nil
+}
+
+// HintError is an error returned by a hint function of the plugin.
+type HintError struct {
+	Message string
+}
+
+func (e *HintError) Error() string {
+	return e.Message
+}
+
+// decoder reads the fields of a payload, recording the first error.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.err = fmt.Errorf("%w: truncated frame", ErrProtocol)
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package external
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	q := big.NewInt(1000003)
+	inputs := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(1000002)}
+
+	payload, err := encodeRequest("square", q, inputs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, payload); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readFrame(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := decodeRequest(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.name != "square" || req.q.Cmp(q) != 0 || req.nbOutputs != 2 || len(req.inputs) != len(inputs) {
+		t.Fatalf("decoded %+v", req)
+	}
+	for i := range inputs {
+		if req.inputs[i].Cmp(inputs[i]) != 0 {
+			t.Fatalf("input %d is %s, expected %s", i, req.inputs[i], inputs[i])
+		}
+	}
+
+	if _, err := encodeRequest("square", q, []*big.Int{q}, 1); err == nil {
+		t.Fatal("expected an error for an input out of range")
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	q := big.NewInt(1000003)
+	outputs := []*big.Int{big.NewInt(42), big.NewInt(1000002)}
+	decoded := []*big.Int{new(big.Int), new(big.Int)}
+	if err := decodeResponse(encodeResponse(q, outputs, nil), q, decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i := range outputs {
+		if decoded[i].Cmp(outputs[i]) != 0 {
+			t.Fatalf("output %d is %s, expected %s", i, decoded[i], outputs[i])
+		}
+	}
+
+	var hintErr *HintError
+	err := decodeResponse(encodeResponse(q, nil, errors.New("boom")), q, decoded)
+	if !errors.As(err, &hintErr) || hintErr.Message != "boom" {
+		t.Fatalf("expected HintError boom, got %v", err)
+	}
+
+	// an output out of range is reported by the plugin
+	err = decodeResponse(encodeResponse(q, []*big.Int{q}, nil), q, decoded[:1])
+	if !errors.As(err, &hintErr) {
+		t.Fatalf("expected a HintError, got %v", err)
+	}
+}
+
+func TestMalformedFrames(t *testing.T) {
+	q := big.NewInt(1000003)
+	payload, err := encodeRequest("sum", q, []*big.Int{big.NewInt(1)}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, p := range map[string][]byte{
+		"empty":     {},
+		"version":   append([]byte{ProtocolVersion + 1}, payload[1:]...),
+		"truncated": payload[:len(payload)-1],
+		"trailing":  append(append([]byte(nil), payload...), 0),
+	} {
+		if _, err := decodeRequest(p); !errors.Is(err, ErrProtocol) {
+			t.Errorf("%s: expected ErrProtocol, got %v", name, err)
+		}
+	}
+
+	if err := decodeResponse(nil, q, nil); !errors.Is(err, ErrProtocol) {
+		t.Errorf("empty response: expected ErrProtocol, got %v", err)
+	}
+	if err := decodeResponse([]byte{statusOK, 1}, q, []*big.Int{new(big.Int)}); !errors.Is(err, ErrProtocol) {
+		t.Errorf("short response: expected ErrProtocol, got %v", err)
+	}
+
+	// frame length over the limit, and frame shorter than its length
+	if _, err := readFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff})); !errors.Is(err, ErrProtocol) {
+		t.Errorf("large frame: expected ErrProtocol, got %v", err)
+	}
+	if _, err := readFrame(bytes.NewReader([]byte{0, 0, 0, 2, 1})); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("short frame: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+
+	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+)
+
+// Serve answers the requests read from r with the given hint functions, indexed by name,
+// and writes the responses to w. It returns nil when r is closed between two requests.
+//
+// Plugins written in Go can call Serve(os.Stdin, os.Stdout, hints) to be run with
+// NewProcess.
+func Serve(r io.Reader, w io.Writer, hints map[string]hintsolver.HintFn) error {
+	for {
+		payload, err := readFrame(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		req, err := decodeRequest(payload)
+		if err != nil {
+			return err
+		}
+		if err := writeFrame(w, serveRequest(req, hints)); err != nil {
+			return err
+		}
+	}
+}
+
+// ServeListener accepts the connections of l, typically a Unix socket, and serves each of
+// them as Serve does, until l is closed.
+func ServeListener(l net.Listener, hints map[string]hintsolver.HintFn) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			Serve(conn, conn, hints) // #nosec G104 -- the client reconnects on errors
+		}()
+	}
+}
+
+// serveRequest returns the response to the request.
+func serveRequest(req *request, hints map[string]hintsolver.HintFn) []byte {
+	fn, ok := hints[req.name]
+	if !ok {
+		return encodeResponse(req.q, nil, fmt.Errorf("unknown hint %s", req.name))
+	}
+	outputs := make([]*big.Int, req.nbOutputs)
+	for i := range outputs {
+		outputs[i] = new(big.Int)
+	}
+	if err := fn(req.q, req.inputs, outputs); err != nil {
+		return encodeResponse(req.q, nil, err)
+	}
+	return encodeResponse(req.q, outputs, nil)
+}
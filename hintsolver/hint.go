@@ -3,6 +3,8 @@ package hintsolver
 import (
 	"hash/fnv"
 	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
 // HintID is a unique identifier for a hint function used for lookup.
@@ -79,14 +81,26 @@ type HintID uint32
 //
 // In the init() method of the gadget, call the method RegisterHint(hintFn) function on
 // the hint function hintFn to register a hint function in the package registry.
+// Alternatively, the hints can be registered in a HintRegistry given to the solver with
+// WithHintRegistry, which doesn't depend on global state.
 type Hint struct {
-	Fn HintFn
-	ID HintID
+	Fn   HintFn
+	ID   HintID
+	Name string // name the ID is derived from, if known
+
+	// NativeFn, if set, is used by the solver instead of Fn. It must compute the same
+	// outputs as Fn.
+	NativeFn NativeHintFn
 }
 
 // HintFn is the function that performs the hint computation.
 type HintFn func(field *big.Int, inputs []*big.Int, outputs []*big.Int) error
 
+// NativeHintFn is a hint function operating on the field elements of the solver directly,
+// without converting the inputs and outputs to and from big.Int. The outputs are zero when
+// the function is called; the slices are reused by the solver and must not be retained.
+type NativeHintFn func(inputs []fr.Element, outputs []fr.Element) error
+
 // GetHintID is a reference function for computing the hint ID based on a function name
 func GetHintID(name string) HintID {
 	hf := fnv.New32a()
@@ -94,10 +108,42 @@ func GetHintID(name string) HintID {
 	return HintID(hf.Sum32())
 }
 
+// WithNative returns a copy of the hint with the native implementation fn of Fn.
+func (h Hint) WithNative(fn NativeHintFn) Hint {
+	h.NativeFn = fn
+	return h
+}
+
+// NewNativeHint creates a new hint with the given name and native function; its Fn
+// converts the big.Int values and calls fn. It does not register the hint in the registry.
+func NewNativeHint(name string, fn NativeHintFn) Hint {
+	return Hint{
+		Fn:       fn.bigIntHint,
+		ID:       GetHintID(name),
+		Name:     name,
+		NativeFn: fn,
+	}
+}
+
+// bigIntHint calls fn with inputs and outputs converted to field elements.
+func (fn NativeHintFn) bigIntHint(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+	in := make([]fr.Element, len(inputs))
+	for i := range inputs {
+		in[i].SetBigInt(inputs[i])
+	}
+	out := make([]fr.Element, len(outputs))
+	err := fn(in, out)
+	for i := range out {
+		out[i].BigInt(outputs[i])
+	}
+	return err
+}
+
 // NewHint creates a new hint with the given name and function. It does not register the hint in the registry.
 func NewHint(name string, fn HintFn) Hint {
 	return Hint{
-		Fn: fn,
-		ID: GetHintID(name),
+		Fn:   fn,
+		ID:   GetHintID(name),
+		Name: name,
 	}
 }
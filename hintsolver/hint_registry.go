@@ -1,47 +1,149 @@
 package hintsolver
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/consensys/gnark/logger"
 )
 
 func init() {
-	RegisterHint(Hint{Fn: InvZeroHint, ID: GetHintID("inv_zero")})
+	RegisterHint(NewHint("inv_zero", InvZeroHint))
 }
 
 var (
-	registry  = make(map[HintID]HintFn)
-	registryM sync.RWMutex
+	// ErrHintRegistered is returned when registering a hint under a name that is already
+	// registered with a different function.
+	ErrHintRegistered = errors.New("hint already registered")
+
+	// ErrHintCollision is returned when two hint names have the same HintID.
+	ErrHintCollision = errors.New("hint id collision")
 )
 
-// RegisterHint registers a hint function in the global registry.
-func RegisterHint(hints ...Hint) {
-	registryM.Lock()
-	defer registryM.Unlock()
+// registry is the global registry, used by the solver unless WithHintRegistry is given.
+var registry = NewHintRegistry()
+
+// HintRegistry is a set of hint functions, indexed by HintID.
+//
+// Since HintID is a 32-bit hash of the hint name, the registry keeps the name of the hints
+// to detect collisions. Hints created without a name (Hint{ID: id, Fn: fn}) are only
+// identified by their id.
+//
+// A HintRegistry is safe for concurrent use. Unlike the global registry, it can be scoped
+// to a constraint system and given to the solver with WithHintRegistry, such that circuits
+// using different implementations of a hint can be solved in the same process.
+type HintRegistry struct {
+	hints map[HintID]Hint
+	m     sync.RWMutex
+}
+
+// NewHintRegistry returns an empty registry.
+func NewHintRegistry() *HintRegistry {
+	return &HintRegistry{hints: make(map[HintID]Hint)}
+}
+
+// Register adds the hints to the registry.
+//
+// Registering the same function under the same name several times is a no-op. Hints
+// conflicting with a registered one are not registered, and the errors (wrapping
+// ErrHintRegistered or ErrHintCollision) are returned joined; the other hints are
+// registered.
+func (r *HintRegistry) Register(hints ...Hint) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var errs []error
 	for _, hint := range hints {
-		if _, ok := registry[hint.ID]; ok {
-			log := logger.Logger()
-			log.Warn().Str("id", fmt.Sprintf("%d", hint.ID)).Msg("function registered multiple times")
-			return
+		if hint.ID == 0 && hint.Name != "" {
+			hint.ID = GetHintID(hint.Name)
+		}
+		registered, ok := r.hints[hint.ID]
+		if !ok {
+			r.hints[hint.ID] = hint
+			continue
+		}
+		switch {
+		case registered.Name != hint.Name && registered.Name != "" && hint.Name != "":
+			errs = append(errs, fmt.Errorf("%w: %s and %s have id %d", ErrHintCollision, registered.Name, hint.Name, hint.ID))
+		case !sameFunction(registered.Fn, hint.Fn) || !sameFunction(registered.NativeFn, hint.NativeFn):
+			errs = append(errs, fmt.Errorf("%w: %s", ErrHintRegistered, hintName(hint)))
 		}
-		registry[hint.ID] = hint.Fn
+	}
+	return errors.Join(errs...)
+}
+
+// Lookup returns the hint registered with the given id.
+func (r *HintRegistry) Lookup(id HintID) (Hint, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	hint, ok := r.hints[id]
+	return hint, ok
+}
+
+// Names returns the sorted names of the registered hints. Hints registered without a name
+// are listed by id.
+func (r *HintRegistry) Names() []string {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	names := make([]string, 0, len(r.hints))
+	for _, hint := range r.hints {
+		names = append(names, hintName(hint))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Hints returns a copy of the registered hints.
+func (r *HintRegistry) Hints() map[HintID]Hint {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	hints := make(map[HintID]Hint, len(r.hints))
+	for id, hint := range r.hints {
+		hints[id] = hint
+	}
+	return hints
+}
+
+// RegisterHint registers hint functions in the global registry. Conflicting hints are not
+// registered, and a warning is logged for each of them; see HintRegistry.Register.
+func RegisterHint(hints ...Hint) {
+	if err := registry.Register(hints...); err != nil {
+		log := logger.Logger()
+		log.Warn().Err(err).Msg("hint function registration failed")
 	}
 }
 
 // GetRegisteredHints returns all registered hint functions.
 func GetRegisteredHints() map[HintID]HintFn {
-	registryM.RLock()
-	defer registryM.RUnlock()
 	hints := make(map[HintID]HintFn)
-	for id, v := range registry {
-		hints[id] = v
+	for id, hint := range registry.Hints() {
+		hints[id] = hint.Fn
 	}
 	return hints
 }
 
+// GetRegisteredHintNames returns the sorted names of the hints in the global registry.
+func GetRegisteredHintNames() []string {
+	return registry.Names()
+}
+
+// hintName returns the name of the hint, or its id if it has no name.
+func hintName(hint Hint) string {
+	if hint.Name == "" {
+		return fmt.Sprintf("<%d>", hint.ID)
+	}
+	return hint.Name
+}
+
+// sameFunction reports whether f and g have the same code. Closures of a same function
+// literal can't be told apart.
+func sameFunction(f, g any) bool {
+	return reflect.ValueOf(f).Pointer() == reflect.ValueOf(g).Pointer()
+}
+
 // InvZeroHint computes the value 1/a for the single input a. If a == 0, returns 0.
 func InvZeroHint(q *big.Int, inputs []*big.Int, results []*big.Int) error {
 	result := results[0]
@@ -14,25 +14,35 @@ type Option func(*Config) error
 
 // Config is the configuration for the solver with the options applied.
 type Config struct {
-	HintFunctions map[HintID]HintFn // defaults to all built-in hint functions
-	Logger        zerolog.Logger    // defaults to gnark.Logger
+	HintFunctions       map[HintID]HintFn       // defaults to all built-in hint functions
+	NativeHintFunctions map[HintID]NativeHintFn // native implementations, used instead of HintFunctions
+	HintNames           map[HintID]string       // names of the hint functions, when known
+	Logger              zerolog.Logger          // defaults to gnark.Logger
+	Zeroize             bool                    // wipe intermediate values, see WithZeroization
+
+	registry  *HintRegistry // defaults to the global registry
+	hints     []Hint        // added with WithHints
+	overrides map[HintID]Hint
 }
 
 // WithHints is a solver option that specifies additional hint functions to be used
 // by the constraint solver.
 func WithHints(hintFunctions ...Hint) Option {
-	log := logger.Logger()
 	return func(opt *Config) error {
-		// it is an error to register hint function several times, but as the
-		// prover already checks it then omit here.
-		for _, h := range hintFunctions {
-			uuid := h.ID
-			if _, ok := opt.HintFunctions[uuid]; ok {
-				log.Warn().Int("hintID", int(uuid)).Str("id", fmt.Sprintf("%d", h.ID)).Msg("duplicate hint function")
-			} else {
-				opt.HintFunctions[uuid] = h.Fn
-			}
+		opt.hints = append(opt.hints, hintFunctions...)
+		return nil
+	}
+}
+
+// WithHintRegistry is a solver option that makes the solver use the hint functions of r
+// instead of the ones of the global registry. Hints given with WithHints and OverrideHint
+// still apply.
+func WithHintRegistry(r *HintRegistry) Option {
+	return func(opt *Config) error {
+		if r == nil {
+			return fmt.Errorf("nil hint registry")
 		}
+		opt.registry = r
 		return nil
 	}
 }
@@ -40,7 +50,21 @@ func WithHints(hintFunctions ...Hint) Option {
 // OverrideHint forces the solver to use provided hint function for given id.
 func OverrideHint(id HintID, f HintFn) Option {
 	return func(opt *Config) error {
-		opt.HintFunctions[id] = f
+		if opt.overrides == nil {
+			opt.overrides = make(map[HintID]Hint)
+		}
+		opt.overrides[id] = Hint{ID: id, Fn: f}
+		return nil
+	}
+}
+
+// OverrideNativeHint forces the solver to use provided native hint function for given id.
+func OverrideNativeHint(id HintID, f NativeHintFn) Option {
+	return func(opt *Config) error {
+		if opt.overrides == nil {
+			opt.overrides = make(map[HintID]Hint)
+		}
+		opt.overrides[id] = Hint{ID: id, Fn: f.bigIntHint, NativeFn: f}
 		return nil
 	}
 }
@@ -55,17 +79,59 @@ func WithLogger(l zerolog.Logger) Option {
 	}
 }
 
+// WithZeroization is a solver option that makes the solver wipe the temporary big.Int
+// hint inputs and outputs before returning them to the pool, and its wire values if
+// solving fails. On success, the solution should be wiped by the caller once consumed.
+func WithZeroization() Option {
+	return func(opt *Config) error {
+		opt.Zeroize = true
+		return nil
+	}
+}
+
 // NewConfig returns a default SolverConfig with given prover options opts applied.
 func NewConfig(opts ...Option) (Config, error) {
 	log := logger.Logger()
-	opt := Config{Logger: log, HintFunctions: make(map[HintID]HintFn)}
-	for k, v := range GetRegisteredHints() {
-		opt.HintFunctions[k] = v // copy
-	}
+	opt := Config{Logger: log, registry: registry}
 	for _, option := range opts {
 		if err := option(&opt); err != nil {
 			return Config{}, err
 		}
 	}
+
+	registered := opt.registry.Hints()
+	opt.HintFunctions = make(map[HintID]HintFn, len(registered)+len(opt.hints)+len(opt.overrides))
+	opt.NativeHintFunctions = make(map[HintID]NativeHintFn)
+	opt.HintNames = make(map[HintID]string, len(registered)+len(opt.hints))
+	for _, h := range registered {
+		opt.setHint(h)
+	}
+	// it is an error to register hint function several times, but as the
+	// prover already checks it then omit here.
+	for _, h := range opt.hints {
+		if _, ok := opt.HintFunctions[h.ID]; ok {
+			log.Warn().Int("hintID", int(h.ID)).Str("id", hintName(h)).Msg("duplicate hint function")
+			continue
+		}
+		opt.setHint(h)
+	}
+	for _, h := range opt.overrides {
+		opt.setHint(h)
+	}
 	return opt, nil
 }
+
+// setHint sets the functions and name of the hint, replacing any previous ones.
+func (opt *Config) setHint(h Hint) {
+	opt.HintFunctions[h.ID] = h.Fn
+	if h.NativeFn != nil {
+		opt.NativeHintFunctions[h.ID] = h.NativeFn
+	} else {
+		delete(opt.NativeHintFunctions, h.ID)
+	}
+	if h.Name != "" {
+		opt.HintNames[h.ID] = h.Name
+	} else {
+		delete(opt.HintNames, h.ID)
+	}
+}
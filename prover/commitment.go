@@ -15,27 +15,26 @@
 package prover
 
 import (
-	"math/big"
-
 	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 )
 
-func solveCommitmentWire(commitment *curve.G1Affine, publicCommitted []*big.Int) (fr.Element, error) {
-	res, err := fr.Hash(serializeCommitment(commitment.Marshal(), publicCommitted, (fr.Bits-1)/8+1), []byte(cs.CommitmentDst), 1)
+func solveCommitmentWire(commitment *curve.G1Affine, publicCommitted []fr.Element) (fr.Element, error) {
+	res, err := fr.Hash(serializeCommitment(commitment.Marshal(), publicCommitted), []byte(cs.CommitmentDst), 1)
 	return res[0], err
 }
 
-func serializeCommitment(privateCommitment []byte, publicCommitted []*big.Int, fieldByteLen int) []byte {
-	res := make([]byte, len(privateCommitment)+len(publicCommitted)*fieldByteLen)
+func serializeCommitment(privateCommitment []byte, publicCommitted []fr.Element) []byte {
+	res := make([]byte, len(privateCommitment)+len(publicCommitted)*fr.Bytes)
 	copy(res, privateCommitment)
 
 	offset := len(privateCommitment)
-	for _, inJ := range publicCommitted {
-		inJ.FillBytes(res[offset : offset+fieldByteLen])
-		offset += fieldByteLen
+	for j := range publicCommitted {
+		b := publicCommitted[j].Bytes()
+		copy(res[offset:offset+fr.Bytes], b[:])
+		offset += fr.Bytes
 	}
 
 	return res
@@ -0,0 +1,50 @@
+package prover
+
+import "github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
+
+// Option defines option for altering the behavior of the prover (Prove() and ProveFrom()
+// functions). See the descriptions of functions returning instances of this type for
+// implemented options.
+type Option func(*Config) error
+
+// Config is the configuration for the prover with the options applied.
+type Config struct {
+	Zeroize       bool                // wipe the witness and intermediate values, see WithZeroization
+	SolverOptions []hintsolver.Option // options given to the constraint system solver
+}
+
+// WithSolverOptions is a prover option that specifies the options of the constraint system
+// solver, for instance hintsolver.WithHintRegistry.
+func WithSolverOptions(opts ...hintsolver.Option) Option {
+	return func(opt *Config) error {
+		opt.SolverOptions = append(opt.SolverOptions, opts...)
+		return nil
+	}
+}
+
+// WithZeroization is a prover option that minimizes the time secret values sit in memory.
+// Once the proof is generated, or if proving fails, the prover overwrites with zeros:
+//   - the full witness given to Prove;
+//   - the solution of the constraint system and the solver temporary values (see
+//     hintsolver.WithZeroization);
+//   - the copies of the wire values made for the multi-exponentiations, the quotient
+//     polynomial H and the random scalars r and s.
+//
+// The witness bytes read by ProveFrom belong to the caller and are not wiped.
+func WithZeroization() Option {
+	return func(opt *Config) error {
+		opt.Zeroize = true
+		return nil
+	}
+}
+
+// NewConfig returns a default Config with given prover options opts applied.
+func NewConfig(opts ...Option) (Config, error) {
+	var opt Config
+	for _, option := range opts {
+		if err := option(&opt); err != nil {
+			return Config{}, err
+		}
+	}
+	return opt, nil
+}
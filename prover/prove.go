@@ -16,13 +16,18 @@ package prover
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 
 	cs "github.com/vocdoni/gnark-tiny-prover-g16/constraint"
 	"github.com/vocdoni/gnark-tiny-prover-g16/hints"
+	"github.com/vocdoni/gnark-tiny-prover-g16/utils"
 	witness "github.com/vocdoni/gnark-tiny-prover-g16/witness"
 
 	"github.com/vocdoni/gnark-tiny-prover-g16/hintsolver"
@@ -34,7 +39,29 @@ import (
 	"github.com/consensys/gnark/logger"
 )
 
-func GenerateProofGroth16(bccs, bpkey, inputs []byte) ([]byte, []byte, error) {
+// ErrFingerprintMismatch is returned when the constraint system doesn't match the
+// fingerprint expected by the proving key.
+var ErrFingerprintMismatch = errors.New("constraint system fingerprint mismatch")
+
+// GenerateProofGroth16 generates a proof from the binary encodings of the constraint system,
+// the proving key and the full witness, and returns the encoded proof and public witness.
+// The prover options opts are given to ProveFrom, e.g. WithZeroization.
+func GenerateProofGroth16(bccs, bpkey, inputs []byte, opts ...Option) ([]byte, []byte, error) {
+	return generateProofGroth16(bccs, bpkey, inputs, nil, opts)
+}
+
+// GenerateProofGroth16WithFingerprint behaves like GenerateProofGroth16 but refuses to
+// generate a proof if the fingerprint of the constraint system differs from fingerprint,
+// the hex encoded cs.Fingerprint stored along the proving key.
+func GenerateProofGroth16WithFingerprint(bccs, bpkey, inputs []byte, fingerprint string, opts ...Option) ([]byte, []byte, error) {
+	expected, err := cs.ParseFingerprint(fingerprint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return generateProofGroth16(bccs, bpkey, inputs, &expected, opts)
+}
+
+func generateProofGroth16(bccs, bpkey, inputs []byte, fingerprint *cs.Fingerprint, opts []Option) ([]byte, []byte, error) {
 	step := time.Now()
 	ccs := cs.R1CS{}
 	if _, err := ccs.ReadFrom(bytes.NewReader(bccs)); err != nil {
@@ -43,6 +70,12 @@ func GenerateProofGroth16(bccs, bpkey, inputs []byte) ([]byte, []byte, error) {
 	}
 	fmt.Println("ccs loaded, took (s):", time.Since(step))
 
+	if fingerprint != nil {
+		if got := ccs.Fingerprint(); got != *fingerprint {
+			return nil, nil, fmt.Errorf("%w: expected %s, got %s", ErrFingerprintMismatch, fingerprint, got)
+		}
+	}
+
 	step = time.Now()
 	provingKey := ProvingKey{}
 	if _, err := provingKey.UnsafeReadFrom(bytes.NewReader(bpkey)); err != nil {
@@ -51,24 +84,25 @@ func GenerateProofGroth16(bccs, bpkey, inputs []byte) ([]byte, []byte, error) {
 	}
 	fmt.Println("pKey loaded, took (s):", time.Since(step))
 
-	step = time.Now()
-	cWitness, err := witness.New()
-	if err != nil {
-		fmt.Println("error initializing witness: ", err)
-		return nil, nil, fmt.Errorf("error initializing witness: %w", err)
+	// the witness is decoded directly into the solver, we only check its size upfront
+	// such that trailing bytes are rejected before proving.
+	nbPublic, nbSecret := ccs.WitnessSize()
+	if expected := 12 + (nbPublic+nbSecret)*fr.Bytes; len(inputs) != expected {
+		return nil, nil, fmt.Errorf("error reading witness: %w: got %d bytes, expected %d", witness.ErrInvalidWitness, len(inputs), expected)
 	}
-	if _, err := cWitness.ReadFrom(bytes.NewReader(inputs)); err != nil {
-		fmt.Println("error reading witness: ", err)
-		return nil, nil, fmt.Errorf("error reading witness: %w", err)
-	}
-	fmt.Println("witness loaded, took (s):", time.Since(step))
 
-	// Register all hints
+	// Register all hints; the hints the circuit depends on under their upstream gnark names
+	// are given to this solver only.
 	hints.RegisterHints()
+	hintOpt, err := dependencyHints(&ccs)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	step = time.Now()
 	// Generate the proof
-	proof, err := Prove(&ccs, &provingKey, cWitness)
+	opts = append([]Option{WithSolverOptions(hintOpt)}, opts...)
+	proof, publicWitness, err := ProveFrom(&ccs, &provingKey, bytes.NewReader(inputs), opts...)
 	if err != nil {
 		fmt.Printf("error generating proof: %v\n", err)
 		return nil, nil, fmt.Errorf("error generating proof: %w", err)
@@ -80,11 +114,7 @@ func GenerateProofGroth16(bccs, bpkey, inputs []byte) ([]byte, []byte, error) {
 		return nil, nil, fmt.Errorf("error encoding proof: %w", err)
 	}
 
-	// Get public witness part and encode it
-	publicWitness, err := cWitness.Public()
-	if err != nil {
-		return nil, nil, fmt.Errorf("error generating public witness: %w", err)
-	}
+	// encode the public witness
 	publicWitnessBuff := bytes.Buffer{}
 	if _, err := publicWitness.WriteTo(&publicWitnessBuff); err != nil {
 		return nil, nil, fmt.Errorf("error encoding public witness: %w", err)
@@ -92,6 +122,35 @@ func GenerateProofGroth16(bccs, bpkey, inputs []byte) ([]byte, []byte, error) {
 	return proofBuff.Bytes(), publicWitnessBuff.Bytes(), nil
 }
 
+// dependencyHints resolves the hint dependencies of the system, for the gnark version which
+// serialized it, and returns the solver option providing the hints missing from the global
+// registry. Dependencies without implementation, except the commitment hint, are an error.
+func dependencyHints(ccs *cs.R1CS) (hintsolver.Option, error) {
+	resolved, _ := hints.ResolveHintDependencies(ccs.MHintsDependencies, ccs.GnarkVersion)
+	registered := hintsolver.GetRegisteredHints()
+
+	var missing []hintsolver.Hint
+	for _, h := range resolved {
+		if _, ok := registered[h.ID]; !ok {
+			missing = append(missing, h)
+			registered[h.ID] = h.Fn
+		}
+	}
+
+	var unresolved []string
+	for id, name := range ccs.MHintsDependencies {
+		if _, ok := registered[id]; ok || (ccs.CommitmentInfo.Is() && id == ccs.CommitmentInfo.HintID) {
+			continue
+		}
+		unresolved = append(unresolved, name)
+	}
+	if len(unresolved) != 0 {
+		sort.Strings(unresolved)
+		return nil, fmt.Errorf("unresolved hints (gnark %q): %s", ccs.GnarkVersion, strings.Join(unresolved, ", "))
+	}
+	return hintsolver.WithHints(missing...), nil
+}
+
 // Proof represents a Groth16 proof that was encoded with a ProvingKey and can be verified
 // with a valid statement and a VerifyingKey
 // Notation follows Figure 4. in DIZK paper https://eprint.iacr.org/2018/691.pdf
@@ -112,58 +171,121 @@ func (proof *Proof) CurveID() ecc.ID {
 }
 
 // Prove generates the proof of knowledge of a r1cs with full witness (secret + public part).
-func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness) (*Proof, error) {
+func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness, opts ...Option) (*Proof, error) {
+	opt, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if opt.Zeroize {
+		defer fullWitness.Destroy()
+	}
+	if err := fullWitness.Validate(r1cs); err != nil {
+		return nil, err
+	}
+	proof, _, err := prove(r1cs, pk, opt, func(opts ...hintsolver.Option) (any, error) {
+		return r1cs.Solve(fullWitness, opts...)
+	})
+	return proof, err
+}
+
+// ProveFrom generates the proof of knowledge of a r1cs with a binary full witness read from
+// r. The witness is decoded directly into the solver (see cs.R1CS.SolveFrom); the public
+// witness is returned along the proof.
+func ProveFrom(r1cs *cs.R1CS, pk *ProvingKey, r io.Reader, opts ...Option) (*Proof, witness.Witness, error) {
+	opt, err := NewConfig(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, public, err := prove(r1cs, pk, opt, func(opts ...hintsolver.Option) (any, error) {
+		return r1cs.SolveFrom(r, opts...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nbPublic := len(public)
+	// buffered, such that no producer is left blocked if Fill returns early.
+	values := make(chan any, nbPublic)
+	for i := range public {
+		values <- &public[i]
+	}
+	close(values)
+	publicWitness, err := witness.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := publicWitness.Fill(nbPublic, 0, values); err != nil {
+		return nil, nil, err
+	}
+	return proof, publicWitness, nil
+}
+
+// prove generates the proof with the solution returned by solve; it also returns the
+// values of the public wires, after the constant one wire.
+func prove(r1cs *cs.R1CS, pk *ProvingKey, opt Config, solve func(opts ...hintsolver.Option) (any, error)) (*Proof, fr.Vector, error) {
 	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Int("nbConstraints", r1cs.GetNbConstraints()).Str("backend", "groth16").Logger()
 
+	// the wire values are filtered with pk.InfinityA and pk.InfinityB below: a proving key
+	// of another circuit would index them out of range.
+	nbInternal, nbSecret, nbPublic := r1cs.GetNbVariables()
+	if nbWires := nbInternal + nbSecret + nbPublic; len(pk.InfinityA) != nbWires || len(pk.InfinityB) != nbWires {
+		return nil, nil, fmt.Errorf("proving key doesn't match the constraint system: %d wires, expected %d", len(pk.InfinityA), nbWires)
+	}
+
 	proof := &Proof{}
-	solverOpts := []hintsolver.Option{}
+	solverOpts := append([]hintsolver.Option{}, opt.SolverOptions...)
+	if opt.Zeroize {
+		solverOpts = append(solverOpts, hintsolver.WithZeroization())
+	}
 
 	if r1cs.CommitmentInfo.Is() {
-		solverOpts = append(solverOpts, hintsolver.OverrideHint(r1cs.CommitmentInfo.HintID, func(_ *big.Int, in []*big.Int, out []*big.Int) error {
-			// Perf-TODO: Converting these values to big.Int and back may be a performance bottleneck.
-			// If that is the case, figure out a way to feed the solution vector into this function
+		solverOpts = append(solverOpts, hintsolver.OverrideNativeHint(r1cs.CommitmentInfo.HintID, func(in []fr.Element, out []fr.Element) error {
 			if len(in) != r1cs.CommitmentInfo.NbCommitted() { // TODO: Remove
 				return fmt.Errorf("unexpected number of committed variables")
 			}
-			values := make([]fr.Element, r1cs.CommitmentInfo.NbPrivateCommitted)
-			nbPublicCommitted := len(in) - len(values)
-			inPrivate := in[nbPublicCommitted:]
-			for i, inI := range inPrivate {
-				values[i].SetBigInt(inI)
-			}
+			nbPublicCommitted := len(in) - r1cs.CommitmentInfo.NbPrivateCommitted
 
+			// the inputs are wiped by the solver in zeroization mode
 			var err error
-			proof.Commitment, proof.CommitmentPok, err = pk.CommitmentKey.Commit(values)
+			proof.Commitment, proof.CommitmentPok, err = pk.CommitmentKey.Commit(in[nbPublicCommitted:])
 			if err != nil {
 				return err
 			}
 
-			var res fr.Element
-			res, err = solveCommitmentWire(&proof.Commitment, in[:r1cs.CommitmentInfo.NbPublicCommitted()])
-			res.BigInt(out[0])
+			out[0], err = solveCommitmentWire(&proof.Commitment, in[:nbPublicCommitted])
 			return err
 		}))
 	}
 
-	_solution, err := r1cs.Solve(fullWitness, solverOpts...)
+	_solution, err := solve(solverOpts...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	solution := _solution.(*cs.R1CSSolution)
 	wireValues := []fr.Element(solution.W)
+	public := make(fr.Vector, nbPublic-1)
+	copy(public, wireValues[1:nbPublic])
 
 	start := time.Now()
 
 	// H (witness reduction / FFT part)
 	var h []fr.Element
-	chHDone := make(chan struct{}, 1)
+	chHDone := make(chan struct{})
 	go func() {
-		h = computeH(solution.A, solution.B, solution.C, &pk.Domain)
+		h = computeH(solution.A, solution.B, solution.C, &pk.Domain, opt.Zeroize)
+		if opt.Zeroize {
+			// computeH reuses a, b and c unless the domain exceeds their capacity
+			utils.Zeroize(solution.B)
+			utils.Zeroize(solution.C)
+			if len(solution.A) > 0 && &solution.A[0] != &h[0] {
+				utils.Zeroize(solution.A)
+			}
+		}
 		solution.A = nil
 		solution.B = nil
 		solution.C = nil
-		chHDone <- struct{}{}
+		close(chHDone)
 	}()
 
 	// we need to copy and filter the wireValues for each multi exp
@@ -197,11 +319,29 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness) (*Proof,
 	// sample random r and s
 	var r, s big.Int
 	var _r, _s, _kr fr.Element
+
+	if opt.Zeroize {
+		defer func() {
+			// wait for H and the copies of the wire values, the multi exps are done or failed.
+			<-chHDone
+			<-chWireValuesA
+			<-chWireValuesB
+			utils.Zeroize(h)
+			utils.Zeroize(wireValuesA)
+			utils.Zeroize(wireValuesB)
+			solution.Destroy()
+			_r.SetZero()
+			_s.SetZero()
+			_kr.SetZero()
+			utils.ZeroizeBigInt(&r)
+			utils.ZeroizeBigInt(&s)
+		}()
+	}
 	if _, err := _r.SetRandom(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if _, err := _s.SetRandom(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	_kr.Mul(&_r, &_s).Neg(&_kr)
 
@@ -257,6 +397,9 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness) (*Proof,
 
 		// filter the wire values if needed;
 		_wireValues := filter(wireValues, r1cs.CommitmentInfo.PrivateToPublic())
+		if opt.Zeroize && len(_wireValues) != len(wireValues) {
+			defer utils.Zeroize(_wireValues)
+		}
 
 		if _, err := krs.MultiExp(pk.G1.K, _wireValues[r1cs.GetNbPublicVariables():], ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
 			chKrsDone <- err
@@ -325,17 +468,17 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, fullWitness witness.Witness) (*Proof,
 	go computeAR1()
 	go computeBS1()
 	if err := computeBS2(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// wait for all parts of the proof to be computed.
 	if err := <-chKrsDone; err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
-	return proof, nil
+	return proof, public, nil
 }
 
 // if len(toRemove) == 0, returns slice
@@ -361,7 +504,9 @@ func filter(slice []fr.Element, toRemove []int) (r []fr.Element) {
 	return r
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// computeH returns H, reusing the memory of a. If zeroize is set, b and c are overwritten
+// with zeros once consumed.
+func computeH(a, b, c []fr.Element, domain *fft.Domain, zeroize bool) []fr.Element {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
@@ -399,6 +544,10 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 				Mul(&a[i], &den)
 		}
 	})
+	if zeroize {
+		utils.Zeroize(b)
+		utils.Zeroize(c)
+	}
 
 	// ifft_coset
 	domain.FFTInverse(a, fft.DIF, fft.OnCoset())
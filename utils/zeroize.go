@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// Zeroize overwrites the elements of v with zeros.
+func Zeroize(v []fr.Element) {
+	for i := range v {
+		v[i].SetZero()
+	}
+}
+
+// ZeroizeBigInt overwrites the words backing x, up to their capacity, and sets x to 0.
+func ZeroizeBigInt(x *big.Int) {
+	if x == nil {
+		return
+	}
+	words := x.Bits()
+	words = words[:cap(words)]
+	for i := range words {
+		words[i] = 0
+	}
+	x.SetUint64(0)
+}
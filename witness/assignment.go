@@ -0,0 +1,213 @@
+package witness
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// visibility of a leaf, as set by the gnark struct tags
+type visibility uint8
+
+const (
+	unset visibility = iota
+	secret
+	public
+)
+
+var (
+	tBigInt  = reflect.TypeOf(big.Int{})
+	tElement = reflect.TypeOf(fr_bn254.Element{})
+)
+
+// leaf is a value of the assignment, with its path in the struct for error messages.
+type leaf struct {
+	path  string
+	value fr_bn254.Element
+}
+
+// FromAssignment builds a witness from a Go struct annotated like a gnark circuit, without
+// the gnark frontend.
+//
+// The struct is walked depth-first, in field order, as gnark does: public values first,
+// then secret values (unless publicOnly is set). Fields are leaves if they are empty
+// interfaces (such as frontend.Variable), integers, strings, []byte, big.Int or fr.Element
+// (or pointers to them); structs, pointers to structs, arrays and slices are walked.
+//
+// The gnark tag sets the visibility of a field and its children:
+//
+//	X frontend.Variable `gnark:",public"`  // public
+//	Y frontend.Variable `gnark:"y"`        // secret (default), the name is ignored
+//	Z frontend.Variable `gnark:"-"`        // not part of the witness
+//
+// As in gnark, the visibility of a parent overrides the tags of its children.
+//
+// Unexported fields are ignored. Nil values and unsupported field types are reported
+// with their path in an *AssignmentError.
+func FromAssignment(v any, publicOnly bool) (Witness, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: assignment must be a struct, got %T", ErrInvalidWitness, v)
+	}
+
+	w := assignmentWalker{err: &AssignmentError{}}
+	w.walk(rv.Type().Name(), rv, unset)
+	if !w.err.empty() {
+		return nil, w.err
+	}
+
+	nbSecret := len(w.secret)
+	if publicOnly {
+		nbSecret = 0
+	}
+	// the channel is buffered and filled beforehand: Fill may return early, and no
+	// producer must be left blocked.
+	values := make(chan any, len(w.public)+nbSecret)
+	for i := range w.public {
+		values <- &w.public[i].value
+	}
+	for i := 0; i < nbSecret; i++ {
+		values <- &w.secret[i].value
+	}
+	close(values)
+
+	res, err := New()
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Fill(len(w.public), nbSecret, values); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type assignmentWalker struct {
+	public, secret []leaf
+	err            *AssignmentError
+}
+
+func (w *assignmentWalker) fail(path, format string, args ...any) {
+	w.err.Invalid = append(w.err.Invalid, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (w *assignmentWalker) walk(path string, v reflect.Value, vis visibility) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			w.fail(path, "unsupported type %s", v.Type())
+			return
+		}
+		// empty interfaces, such as frontend.Variable, are always leaves.
+		if v.IsNil() {
+			w.fail(path, "nil value")
+			return
+		}
+		w.leaf(path, v.Elem(), vis)
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.fail(path, "nil value")
+			return
+		}
+		if t := v.Type().Elem(); t == tBigInt || t == tElement {
+			w.leaf(path, v, vis)
+			return
+		}
+		w.walk(path, v.Elem(), vis)
+	case reflect.Struct:
+		if t := v.Type(); t == tBigInt || t == tElement {
+			w.leaf(path, v, vis)
+			return
+		}
+		w.walkStruct(path, v, vis)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.leaf(path, v, vis)
+			return
+		}
+		fallthrough
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			w.walk(path+"["+strconv.Itoa(i)+"]", v.Index(i), vis)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.String:
+		w.leaf(path, v, vis)
+	default:
+		w.fail(path, "unsupported type %s", v.Type())
+	}
+}
+
+func (w *assignmentWalker) walkStruct(path string, v reflect.Value, parent visibility) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		vis := parent
+		if tag, ok := sf.Tag.Lookup("gnark"); ok {
+			if tag == "-" {
+				continue
+			}
+			// the visibility of the parent wins, if set.
+			opts := strings.Split(tag, ",")
+			for _, opt := range opts[1:] {
+				switch strings.TrimSpace(opt) {
+				case "public":
+					if parent == unset {
+						vis = public
+					}
+				case "secret":
+					if parent == unset {
+						vis = secret
+					}
+				}
+			}
+		}
+		w.walk(fieldPath, v.Field(i), vis)
+	}
+}
+
+func (w *assignmentWalker) leaf(path string, v reflect.Value, vis visibility) {
+	var l leaf
+	l.path = path
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		l.value.SetInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		l.value.SetUint64(v.Uint())
+	case reflect.String:
+		if _, err := l.value.SetString(v.String()); err != nil {
+			w.fail(path, "%s", err)
+			return
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			w.fail(path, "nil value")
+			return
+		}
+		fallthrough
+	default:
+		if _, err := l.value.SetInterface(v.Interface()); err != nil {
+			w.fail(path, "%s", err)
+			return
+		}
+	}
+	if vis == public {
+		w.public = append(w.public, l)
+	} else {
+		w.secret = append(w.secret, l)
+	}
+}
@@ -0,0 +1,226 @@
+package witness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// AssignmentError reports, by variable name, the problems found while building a witness
+// from named values. It wraps ErrInvalidWitness.
+type AssignmentError struct {
+	Missing []string // variables of the circuit without a value
+	Extra   []string // values matching no variable of the circuit
+	Invalid []string // "name: reason", for values which are not field elements
+}
+
+func (e *AssignmentError) Error() string {
+	var sbb strings.Builder
+	sbb.WriteString(ErrInvalidWitness.Error())
+	write := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		sbb.WriteString("\n")
+		sbb.WriteString(strconv.Itoa(len(names)))
+		sbb.WriteString(title)
+		for _, name := range names {
+			sbb.WriteString("\n\t")
+			sbb.WriteString(name)
+		}
+	}
+	write(" missing value(s):", e.Missing)
+	write(" unknown variable(s):", e.Extra)
+	write(" invalid value(s):", e.Invalid)
+	return sbb.String()
+}
+
+func (e *AssignmentError) Unwrap() error {
+	return ErrInvalidWitness
+}
+
+func (e *AssignmentError) empty() bool {
+	return len(e.Missing)+len(e.Extra)+len(e.Invalid) == 0
+}
+
+// FromJSON builds a witness from a JSON object of named values.
+//
+// public and secret are the names of the public and secret variables of the circuit, in
+// witness order (for a R1CS, cs.R1CS.Public without the constant one wire and
+// cs.R1CS.Secret). With secret empty, FromJSON builds a public witness.
+//
+// Values are JSON integers or strings holding a decimal or 0x prefixed hexadecimal
+// integer, in [0, r). Nested objects and arrays are flattened with gnark naming rules:
+//
+//	{"A": {"B": 1}, "Votes": [1, 2]}  ->  A_B = 1, Votes_0 = 1, Votes_1 = 2
+//
+// Variable names using brackets for arrays (Votes[1]) or dots for nested structures (A.B)
+// are matched as well. All missing, unknown and invalid values are reported in an
+// *AssignmentError.
+func FromJSON(data []byte, public, secret []string) (Witness, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]any
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidWitness, err)
+	}
+
+	values := make(map[string]any)
+	aliases := make(map[string]string) // normalized name to JSON path, for error messages
+	aErr := &AssignmentError{}
+	var flatten func(path, name string, v any)
+	flatten = func(path, name string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				flatten(joinPath(path, k), joinName(name, normalizeName(k)), v[k])
+			}
+		case []any:
+			for i, e := range v {
+				flatten(path+"["+strconv.Itoa(i)+"]", joinName(name, strconv.Itoa(i)), e)
+			}
+		default:
+			if _, ok := aliases[name]; ok {
+				aErr.Invalid = append(aErr.Invalid, path+": value set more than once")
+				return
+			}
+			aliases[name] = path
+			values[name] = v
+		}
+	}
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		flatten(k, normalizeName(k), object[k])
+	}
+
+	return fromValues(values, aliases, aErr, public, secret)
+}
+
+// FromValues builds a witness from values keyed by variable name. Names are matched as in
+// FromJSON, and values are *big.Int, big.Int, fr.Element, *fr.Element, integers or
+// strings holding a decimal or 0x prefixed hexadecimal integer, in [0, r).
+//
+// With secret empty, FromValues builds a public witness.
+func FromValues(values map[string]any, public, secret []string) (Witness, error) {
+	normalized := make(map[string]any, len(values))
+	aliases := make(map[string]string, len(values))
+	aErr := &AssignmentError{}
+	for name, v := range values {
+		key := normalizeName(name)
+		if _, ok := aliases[key]; ok {
+			aErr.Invalid = append(aErr.Invalid, name+": value set more than once")
+			continue
+		}
+		aliases[key] = name
+		normalized[key] = v
+	}
+	sort.Strings(aErr.Invalid)
+	return fromValues(normalized, aliases, aErr, public, secret)
+}
+
+// fromValues builds the witness from values keyed by normalized name; aliases maps the
+// normalized names to the names used in error messages.
+func fromValues(values map[string]any, aliases map[string]string, aErr *AssignmentError, public, secret []string) (Witness, error) {
+	vector := make(fr_bn254.Vector, len(public)+len(secret))
+	for i, name := range append(append([]string(nil), public...), secret...) {
+		key := normalizeName(name)
+		v, ok := values[key]
+		if !ok {
+			aErr.Missing = append(aErr.Missing, name)
+			continue
+		}
+		delete(values, key)
+		if err := setCanonical(&vector[i], v); err != nil {
+			aErr.Invalid = append(aErr.Invalid, name+": "+err.Error())
+		}
+	}
+	for name := range values {
+		aErr.Extra = append(aErr.Extra, aliases[name])
+	}
+	sort.Strings(aErr.Extra)
+	if !aErr.empty() {
+		return nil, aErr
+	}
+
+	return &witness{
+		vector:   vector,
+		nbPublic: uint32(len(public)),
+		nbSecret: uint32(len(secret)),
+	}, nil
+}
+
+// setCanonical sets e to v, a json.Number, a decimal or hexadecimal string or a value
+// accepted by checkCanonical. Unlike SetInterface, it rejects values which are not in [0, r).
+func setCanonical(e *fr_bn254.Element, v any) error {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	case nil:
+		return fmt.Errorf("null value")
+	case fr_bn254.Element, *fr_bn254.Element, *big.Int, big.Int,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if err := checkCanonical(v); err != nil {
+			return err
+		}
+		_, err := e.SetInterface(v)
+		return err
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+
+	b := new(big.Int)
+	var ok bool
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		_, ok = b.SetString(s[2:], 16)
+	} else {
+		_, ok = b.SetString(s, 10)
+	}
+	if !ok {
+		return fmt.Errorf("%q is not an integer", s)
+	}
+	if b.Sign() < 0 || b.Cmp(fr_bn254.Modulus()) >= 0 {
+		return fmt.Errorf("%s is not in the scalar field", s)
+	}
+	e.SetBigInt(b)
+	return nil
+}
+
+// normalizeName maps the array and nested structure notations A[1] and A.B to the gnark
+// variable names A_1 and A_B.
+func normalizeName(name string) string {
+	name = strings.ReplaceAll(name, "]", "")
+	name = strings.ReplaceAll(name, "[", "_")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+func joinName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "_" + name
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
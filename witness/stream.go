@@ -0,0 +1,192 @@
+package witness
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// streamChunk is the number of values buffered by Decoder and Encoder.
+const streamChunk = 512
+
+// Decoder reads a binary witness incrementally from an io.Reader, with a bounded buffer,
+// such that the values can be decoded directly into their destination (for instance the
+// solver value vector) without materializing the witness.
+//
+// The header is checked as in strict mode, and non-canonical values are rejected. The
+// decoder never reads past the end of the witness, and overwrites its buffer once the
+// values are decoded.
+type Decoder struct {
+	r                  io.Reader
+	nbPublic, nbSecret int
+	remaining          int
+	n                  int64
+	buf                []byte
+}
+
+// NewDecoder reads the witness header from r and returns a decoder for its values. The
+// header must match the vector length, which must not exceed maxSize (0 for no limit).
+func NewDecoder(r io.Reader, maxSize int) (*Decoder, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	nbPublic := binary.BigEndian.Uint32(header[:4])
+	nbSecret := binary.BigEndian.Uint32(header[4:8])
+	size := binary.BigEndian.Uint32(header[8:12])
+	if uint64(nbPublic)+uint64(nbSecret) != uint64(size) {
+		return nil, fmt.Errorf("%w: header declares %d public and %d secret values, vector has %d", ErrInvalidWitness, nbPublic, nbSecret, size)
+	}
+	if maxSize > 0 && uint64(size) > uint64(maxSize) {
+		return nil, fmt.Errorf("%w: %d values exceed the maximum size %d", ErrInvalidWitness, size, maxSize)
+	}
+	return &Decoder{
+		r:         r,
+		nbPublic:  int(nbPublic),
+		nbSecret:  int(nbSecret),
+		remaining: int(size),
+		n:         int64(len(header)),
+	}, nil
+}
+
+// Size returns the number of public and secret values declared by the header.
+func (d *Decoder) Size() (nbPublic, nbSecret int) {
+	return d.nbPublic, d.nbSecret
+}
+
+// Remaining returns the number of values not read yet.
+func (d *Decoder) Remaining() int {
+	return d.remaining
+}
+
+// BytesRead returns the number of bytes read from the underlying reader.
+func (d *Decoder) BytesRead() int64 {
+	return d.n
+}
+
+// Read decodes up to len(dst) values into dst and returns the number of values decoded.
+// It returns io.EOF once all the values have been read.
+func (d *Decoder) Read(dst []fr_bn254.Element) (int, error) {
+	if d.remaining == 0 {
+		return 0, io.EOF
+	}
+	if len(dst) > d.remaining {
+		dst = dst[:d.remaining]
+	}
+	if d.buf == nil {
+		d.buf = make([]byte, streamChunk*fr_bn254.Bytes)
+	}
+
+	read := 0
+	for read < len(dst) {
+		m := len(dst) - read
+		if m > streamChunk {
+			m = streamChunk
+		}
+		buf := d.buf[:m*fr_bn254.Bytes]
+		k, err := io.ReadFull(d.r, buf)
+		d.n += int64(k)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == nil {
+			var decoded int
+			decoded, err = d.decode(dst[read:read+m], buf)
+			read += decoded
+		}
+		for i := range buf {
+			buf[i] = 0
+		}
+		if err != nil {
+			return read, err
+		}
+	}
+	return read, nil
+}
+
+// decode decodes buf into dst, which must be len(buf) / fr.Bytes long, and returns the
+// number of values decoded.
+func (d *Decoder) decode(dst []fr_bn254.Element, buf []byte) (int, error) {
+	for i := range dst {
+		e, err := fr_bn254.BigEndian.Element((*[fr_bn254.Bytes]byte)(buf[i*fr_bn254.Bytes:]))
+		if err != nil {
+			return i, fmt.Errorf("%w: value %d: %s", ErrInvalidWitness, d.nbPublic+d.nbSecret-d.remaining, err)
+		}
+		dst[i] = e
+		d.remaining--
+	}
+	return len(dst), nil
+}
+
+// Encoder writes a binary witness incrementally to an io.Writer, with a bounded buffer.
+type Encoder struct {
+	w         *bufio.Writer
+	remaining int
+	n         int64
+	err       error
+}
+
+// NewEncoder writes the header of a witness with nbPublic public and nbSecret secret
+// values to w, and returns an encoder for its values.
+func NewEncoder(w io.Writer, nbPublic, nbSecret int) (*Encoder, error) {
+	if nbPublic < 0 || nbSecret < 0 || uint64(nbPublic)+uint64(nbSecret) > 1<<32-1 {
+		return nil, fmt.Errorf("%w: invalid size %d + %d", ErrInvalidWitness, nbPublic, nbSecret)
+	}
+	e := &Encoder{
+		w:         bufio.NewWriterSize(w, streamChunk*fr_bn254.Bytes),
+		remaining: nbPublic + nbSecret,
+	}
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(nbPublic))
+	binary.BigEndian.PutUint32(header[4:8], uint32(nbSecret))
+	binary.BigEndian.PutUint32(header[8:12], uint32(nbPublic+nbSecret))
+	e.write(header[:])
+	return e, e.err
+}
+
+func (e *Encoder) write(b []byte) {
+	if e.err != nil {
+		return
+	}
+	var k int
+	k, e.err = e.w.Write(b)
+	e.n += int64(k)
+}
+
+// Write encodes the values, public values first. It fails if more values than declared
+// in the header are written.
+func (e *Encoder) Write(values ...fr_bn254.Element) error {
+	if e.err != nil {
+		return e.err
+	}
+	if len(values) > e.remaining {
+		return fmt.Errorf("%w: %d values exceed the declared size", ErrInvalidWitness, len(values)-e.remaining)
+	}
+	for i := range values {
+		b := values[i].Bytes()
+		e.write(b[:])
+	}
+	e.remaining -= len(values)
+	return e.err
+}
+
+// Flush writes the buffered data to the underlying writer. It fails if fewer values than
+// declared in the header were written; the underlying writer is not closed.
+func (e *Encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.remaining != 0 {
+		return fmt.Errorf("%w: %d values missing", ErrInvalidWitness, e.remaining)
+	}
+	e.err = e.w.Flush()
+	return e.err
+}
+
+// BytesWritten returns the number of bytes written, including buffered ones.
+func (e *Encoder) BytesWritten() int64 {
+	return e.n
+}
@@ -2,6 +2,8 @@ package witness
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"reflect"
 
 	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
@@ -45,6 +47,52 @@ func set(v any, index int, value any) error {
 	}
 }
 
+// checkCanonical returns an error if value is an integer outside of [0, r). Other values
+// are checked by set.
+func checkCanonical(value any) error {
+	b := new(big.Int)
+	switch v := value.(type) {
+	case *big.Int:
+		if v == nil {
+			return errors.New("nil value")
+		}
+		b = v
+	case big.Int:
+		b = &v
+	case string:
+		if _, ok := b.SetString(v, 0); !ok {
+			return fmt.Errorf("%q is not an integer", v)
+		}
+	case []byte:
+		b.SetBytes(v)
+	case int:
+		b.SetInt64(int64(v))
+	case int8:
+		b.SetInt64(int64(v))
+	case int16:
+		b.SetInt64(int64(v))
+	case int32:
+		b.SetInt64(int64(v))
+	case int64:
+		b.SetInt64(v)
+	default:
+		return nil
+	}
+	if b.Sign() < 0 || b.Cmp(fr_bn254.Modulus()) >= 0 {
+		return fmt.Errorf("%s is not in the scalar field", b)
+	}
+	return nil
+}
+
+func vectorLen(v any) int {
+	switch pv := v.(type) {
+	case fr_bn254.Vector:
+		return len(pv)
+	default:
+		panic("invalid input")
+	}
+}
+
 func iterate(v any) chan any {
 	chValues := make(chan any)
 	switch pv := v.(type) {
@@ -69,3 +117,19 @@ func resize(v any, n int) any {
 		panic("invalid input")
 	}
 }
+
+// zeroize overwrites the elements of the vector with zeros.
+func zeroize(v any) {
+	switch pv := v.(type) {
+	case fr_bn254.Vector:
+		for i := range pv {
+			pv[i].SetZero()
+		}
+	case []fr_bn254.Element:
+		for i := range pv {
+			pv[i].SetZero()
+		}
+	default:
+		panic("invalid input")
+	}
+}
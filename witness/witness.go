@@ -49,6 +49,9 @@ import (
 
 var ErrInvalidWitness = errors.New("invalid witness")
 
+// maxPreallocated bounds the number of elements allocated upfront by the strict decoder.
+const maxPreallocated = 1 << 16
+
 // Witness represents a zkSNARK witness.
 //
 // The underlying data structure is a vector of field elements, but a Witness
@@ -73,27 +76,69 @@ type Witness interface {
 	// Will allocate the underlying vector with nbPublic + nbSecret elements.
 	// This is typically call by internal APIs to fill the vector by walking a structure.
 	Fill(nbPublic, nbSecret int, values <-chan any) error
+
+	// Validate checks that the witness is a full witness for the constraint system: its
+	// public and secret counts match the ones of ccs and the underlying vector.
+	Validate(ccs ConstraintSystem) error
+
+	// Destroy overwrites the underlying vector with zeros and resets the witness to an
+	// empty one, such that secret values don't linger in memory. Copies previously
+	// returned by Public or Vector are not wiped.
+	Destroy()
+}
+
+// ConstraintSystem is the subset of a constraint system needed to validate a witness.
+type ConstraintSystem interface {
+	// WitnessSize returns the number of public and secret values of a full witness.
+	WitnessSize() (nbPublic, nbSecret int)
 }
 
 type witness struct {
 	vector             any
 	nbPublic, nbSecret uint32
+
+	strict  bool
+	maxSize int // max number of elements in strict mode, 0 for no limit
+}
+
+// Option configures a Witness created with New.
+type Option func(*witness)
+
+// WithStrictDecoding enables the strict mode of the witness:
+//   - ReadFrom and UnmarshalBinary check that the header matches the vector length, and
+//     that it doesn't exceed maxSize elements (0 for no limit) before allocating it;
+//   - UnmarshalBinary rejects trailing bytes;
+//   - Fill rejects values outside of [0, r) instead of reducing them modulo r.
+//
+// Non-canonical field element encodings are rejected in both modes.
+func WithStrictDecoding(maxSize int) Option {
+	return func(w *witness) {
+		w.strict = true
+		w.maxSize = maxSize
+	}
 }
 
 // New initialize a new empty Witness.
-func New() (Witness, error) {
+func New(opts ...Option) (Witness, error) {
 	v, err := newVector(0)
 	if err != nil {
 		return nil, err
 	}
 
-	return &witness{
+	w := &witness{
 		vector: v,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, nil
 }
 
 func (w *witness) Fill(nbPublic, nbSecret int, values <-chan any) error {
 	n := nbPublic + nbSecret
+	if w.strict && w.maxSize > 0 && n > w.maxSize {
+		return fmt.Errorf("%w: %d values exceed the maximum size %d", ErrInvalidWitness, n, w.maxSize)
+	}
 	w.vector = resize(w.vector, n)
 	w.nbPublic = uint32(nbPublic)
 	w.nbSecret = uint32(nbSecret)
@@ -111,6 +156,11 @@ func (w *witness) Fill(nbPublic, nbSecret int, values <-chan any) error {
 		// 	this is caught in the set method. however, error message will be unclear; reason
 		// is there is a nil field in assignment, we could print which one.
 		// }
+		if w.strict {
+			if err := checkCanonical(v); err != nil {
+				return fmt.Errorf("%w: value %d: %s", ErrInvalidWitness, i, err)
+			}
+		}
 		if err := set(w.vector, i, v); err != nil {
 			return err
 		}
@@ -163,6 +213,9 @@ func (w *witness) WriteTo(wr io.Writer) (n int64, err error) {
 }
 
 func (w *witness) ReadFrom(r io.Reader) (n int64, err error) {
+	if w.strict {
+		return w.readStrict(r)
+	}
 	var buf [4]byte
 	if read, err := io.ReadFull(r, buf[:]); err != nil {
 		return int64(read), err
@@ -199,8 +252,61 @@ func (w *witness) MarshalBinary() (data []byte, err error) {
 // UnmarshalBinary implements encoding.BinaryUnmarshaler
 func (w *witness) UnmarshalBinary(data []byte) error {
 	r := bytes.NewReader(data)
-	_, err := w.ReadFrom(r)
-	return err
+	if _, err := w.ReadFrom(r); err != nil {
+		return err
+	}
+	if w.strict && r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidWitness, r.Len())
+	}
+	return nil
+}
+
+// readStrict decodes the witness, checking the header against the vector length and the
+// maximum size before allocating the vector.
+func (w *witness) readStrict(r io.Reader) (int64, error) {
+	dec, err := NewDecoder(r, w.maxSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// the vector grows as values are read, a short input can't trigger a large allocation.
+	capacity := dec.Remaining()
+	if capacity > maxPreallocated {
+		capacity = maxPreallocated
+	}
+	vector := make(fr_bn254.Vector, 0, capacity)
+	var chunk [streamChunk]fr_bn254.Element
+	defer zeroize(chunk[:])
+	for dec.Remaining() > 0 {
+		k, err := dec.Read(chunk[:])
+		vector = append(vector, chunk[:k]...)
+		if err != nil {
+			return dec.BytesRead(), err
+		}
+	}
+
+	nbPublic, nbSecret := dec.Size()
+	w.vector = vector
+	w.nbPublic = uint32(nbPublic)
+	w.nbSecret = uint32(nbSecret)
+	return dec.BytesRead(), nil
+}
+
+func (w *witness) Validate(ccs ConstraintSystem) error {
+	nbPublic, nbSecret := ccs.WitnessSize()
+	if int(w.nbPublic) != nbPublic || int(w.nbSecret) != nbSecret {
+		return fmt.Errorf("%w: got %d public and %d secret values, expected %d and %d", ErrInvalidWitness, w.nbPublic, w.nbSecret, nbPublic, nbSecret)
+	}
+	if n := vectorLen(w.vector); n != nbPublic+nbSecret {
+		return fmt.Errorf("%w: vector has %d values, expected %d", ErrInvalidWitness, n, nbPublic+nbSecret)
+	}
+	return nil
+}
+
+func (w *witness) Destroy() {
+	zeroize(w.vector)
+	w.vector = resize(w.vector, 0)
+	w.nbPublic, w.nbSecret = 0, 0
 }
 
 func (w *witness) Vector() any {